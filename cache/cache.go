@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"errors"
 	"time"
 )
 
@@ -21,6 +22,19 @@ type Cache interface {
 	Keys(pattern string) ([]string, error)
 }
 
+// ErrLockNotObtained is returned by Locker when the lock is held by another owner
+var ErrLockNotObtained = errors.New("lock not obtained")
+
+// Locker defines a distributed lock, Lock returns a token which is required to
+// release or refresh the lock, so only the owner can unlock it.
+type Locker interface {
+	Lock(key string, expiration time.Duration) (token string, err error)
+	// Refresh resets the expiration of the lock, it returns ErrLockNotObtained
+	// if the lock is no longer owned by the token.
+	Refresh(key string, token string, expiration time.Duration) error
+	Unlock(key string, token string) error
+}
+
 // KeyFn defines a transformer for cache keys
 type KeyFn func(string) string
 
@@ -0,0 +1,24 @@
+package redis
+
+import (
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// NewClient creates a go-redis client with the given config and checks the
+// connection by sending a ping, the client can be shared with other packages
+// built on top of redis.
+func NewClient(config Config) (*goredis.Client, error) {
+	rdb := goredis.NewClient(&goredis.Options{
+		Addr:     config.Address,
+		Password: config.Password,
+		DB:       config.Database,
+	})
+
+	err := rdb.Ping(ctx).Err()
+	if err != nil {
+		rdb.Close()
+		return nil, err
+	}
+
+	return rdb, nil
+}
@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	"github.com/quangdangfit/gosdk/cache"
+	"github.com/quangdangfit/gosdk/utils/logger"
+)
+
+// unlockScript deletes the key only when it still holds the token of the caller
+var unlockScript = goredis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// refreshScript extends the key only when it still holds the token of the caller
+var refreshScript = goredis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type locker struct {
+	cmd   goredis.Cmdable
+	keyFn cache.KeyFn
+}
+
+// NewLocker creates a distributed lock backed by redis, the key of lock will be
+// transformed by the key function option.
+func NewLocker(config Config, opts ...Option) cache.Locker {
+	rdb, err := NewClient(config)
+	if err != nil {
+		logger.Error(err)
+		return nil
+	}
+
+	opt := getConfig(opts...)
+
+	return &locker{
+		cmd:   rdb,
+		keyFn: opt.keyFn,
+	}
+}
+
+// Lock sets the key if it does not exist, the lock will be released automatically
+// after expiration. It returns cache.ErrLockNotObtained if the key is held.
+func (l *locker) Lock(key string, expiration time.Duration) (string, error) {
+	if expiration == 0 {
+		expiration = cache.DefaultExpiration
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := l.cmd.SetNX(ctx, l.keyFn(key), token, expiration).Result()
+	if err != nil {
+		logger.Errorf("Failed to lock %s: %s", key, err)
+		return "", err
+	}
+	if !ok {
+		return "", cache.ErrLockNotObtained
+	}
+
+	return token, nil
+}
+
+// Refresh resets the expiration of the lock if it is still owned by the token,
+// it returns cache.ErrLockNotObtained otherwise.
+func (l *locker) Refresh(key string, token string, expiration time.Duration) error {
+	if expiration == 0 {
+		expiration = cache.DefaultExpiration
+	}
+
+	ok, err := refreshScript.Run(ctx, l.cmd, []string{l.keyFn(key)}, token, expiration.Milliseconds()).Int()
+	if err != nil {
+		logger.Errorf("Failed to refresh lock %s: %s", key, err)
+		return err
+	}
+	if ok == 0 {
+		return cache.ErrLockNotObtained
+	}
+
+	return nil
+}
+
+// Unlock releases the lock if it is still owned by the token.
+func (l *locker) Unlock(key string, token string) error {
+	err := unlockScript.Run(ctx, l.cmd, []string{l.keyFn(key)}, token).Err()
+	if err != nil {
+		logger.Errorf("Failed to unlock %s: %s", key, err)
+		return err
+	}
+
+	return nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
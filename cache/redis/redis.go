@@ -20,15 +20,9 @@ type redis struct {
 }
 
 func New(config Config, opts ...Option) cache.Cache {
-	rdb := goredis.NewClient(&goredis.Options{
-		Addr:     config.Address,
-		Password: config.Password,
-		DB:       config.Database,
-	})
-
-	pong, err := rdb.Ping(ctx).Result()
+	rdb, err := NewClient(config)
 	if err != nil {
-		logger.Error(pong, err)
+		logger.Error(err)
 		return nil
 	}
 
@@ -0,0 +1,337 @@
+// Package audit wraps a database.Mongo so every write is recorded in an audit
+// collection: the actor, the collection, the filter and the payload of the
+// inserts, updates, upserts, deletes, bulk writes and ApplyDB. The payload of an
+// update is the update document, which holds the changed fields. The fields of
+// the redaction rules are replaced by Redacted. If the wrapped database
+// implements database.MongoContext, so does the wrapper.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/utils/logger"
+	"github.com/quangdangfit/gosdk/utils/paging"
+)
+
+// DefaultCollection is the default collection of the audit entries
+const DefaultCollection = "audit_logs"
+
+// Entry is the audit entry of a write. The filter and payload are redacted and
+// encoded in JSON, as the keys of operators and dotted keys cannot be stored by
+// every server version.
+type Entry struct {
+	Actor      string `json:"actor" bson:"actor"`
+	Operation  string `json:"operation" bson:"operation"`
+	Collection string `json:"collection" bson:"collection"`
+	Filter     string `json:"filter,omitempty" bson:"filter,omitempty"`
+	// Payload is the inserted documents, the update document or replacement, or
+	// the operations of a bulk write
+	Payload string `json:"payload,omitempty" bson:"payload,omitempty"`
+	// Affected is the number of inserted, matched, upserted or deleted documents
+	Affected  int64     `json:"affected" bson:"affected"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+}
+
+// Mongo is a database.Mongo which audits the writes
+type Mongo interface {
+	db.Mongo
+
+	// WithContext returns a view of the database whose writes are audited with
+	// the actor of ctx
+	WithContext(ctx context.Context) Mongo
+}
+
+type actorKey struct{}
+
+// ContextWithActor returns a copy of ctx with the actor, e.g. the id of the
+// authenticated user
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of ctx, empty if there is none
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+type audit struct {
+	database db.Mongo
+	opt      *option
+	ctx      context.Context
+}
+
+var _ Mongo = (*audit)(nil)
+
+// New wraps the database so its writes are audited, the entries are inserted in
+// the audit collection of the database. A write is audited once it succeeded, a
+// failure to write its entry is logged and does not fail the write.
+//
+// If the database implements database.MongoContext, the returned Mongo
+// implements it too. The context operations are audited with the actor of their
+// ctx, and the writes of WithTransaction are audited in the transaction.
+func New(database db.Mongo, opts ...Option) Mongo {
+	return wrap(database, getOption(opts...), context.Background())
+}
+
+// wrap returns the audit of the database with the actor of ctx, which implements
+// database.MongoContext if the database does
+func wrap(database db.Mongo, opt *option, ctx context.Context) Mongo {
+	a := &audit{database: database, opt: opt, ctx: ctx}
+	if c, ok := database.(db.MongoContext); ok {
+		return &auditContext{audit: a, ctxDatabase: c}
+	}
+
+	return a
+}
+
+func (a *audit) WithContext(ctx context.Context) Mongo {
+	return wrap(a.database, a.opt, ctx)
+}
+
+// record inserts the audit entry of the write with the redacted filter and
+// payload
+func (a *audit) record(operation string, table string, filter db.Filter, payload interface{}, affected int64) {
+	entry := Entry{Operation: operation, Collection: table, Affected: affected}
+	if filter != nil {
+		entry.Filter = a.encode(table, filter)
+	}
+	if payload != nil {
+		entry.Payload = a.encode(table, payload)
+	}
+
+	a.write(entry)
+}
+
+func (a *audit) write(entry Entry) {
+	if entry.Collection == a.opt.collection {
+		return
+	}
+
+	entry.Actor = a.opt.actor(a.ctx)
+	entry.Timestamp = time.Now().UTC()
+	_, err := a.database.InsertOne(a.opt.collection, entry)
+	if err != nil {
+		logger.Error("[Audit] Failed to write audit entry of ", entry.Operation, " ", entry.Collection, ": ", err)
+	}
+}
+
+// redact returns the normalized value with the fields of the rules of the
+// collection redacted
+func (a *audit) redact(table string, v interface{}) (interface{}, error) {
+	normalized, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := append(redactor{}, a.opt.redact[""]...)
+	rules = append(rules, a.opt.redact[table]...)
+	return rules.redact(normalized, ""), nil
+}
+
+// encode returns the redacted value in JSON, it is empty if the value cannot be
+// redacted so no field is leaked
+func (a *audit) encode(table string, v interface{}) string {
+	redacted, err := a.redact(table, v)
+	if err != nil {
+		logger.Error("[Audit] Failed to redact ", table, ": ", err)
+		return ""
+	}
+
+	return encodeJSON(redacted)
+}
+
+func encodeJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Error("[Audit] Failed to encode: ", err)
+		return ""
+	}
+
+	return string(data)
+}
+
+func (a *audit) EnsureIndex(collectionName string, index db.IndexConfig) bool {
+	return a.database.EnsureIndex(collectionName, index)
+}
+
+func (a *audit) DropIndex(collectionName string, name string) bool {
+	return a.database.DropIndex(collectionName, name)
+}
+
+func (a *audit) ListIndexes(collectionName string) ([]db.IndexConfig, error) {
+	return a.database.ListIndexes(collectionName)
+}
+
+func (a *audit) DropCollection(collectionName string) error {
+	return a.database.DropCollection(collectionName)
+}
+
+func (a *audit) FindOne(table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	return a.database.FindOne(table, query, opts, result)
+}
+
+func (a *audit) FindMany(table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	return a.database.FindMany(table, query, opts, result)
+}
+
+func (a *audit) FindManyPaging(table string, query db.Filter, opts *db.FindOptions, offset int, limit int, result interface{}, pagingOpts ...paging.Option) (*paging.Paging, error) {
+	return a.database.FindManyPaging(table, query, opts, offset, limit, result, pagingOpts...)
+}
+
+func (a *audit) FindManyCursor(table string, query db.Filter, sort db.Sort, cursor string, limit int, result interface{}) (*db.Cursor, error) {
+	return a.database.FindManyCursor(table, query, sort, cursor, limit, result)
+}
+
+func (a *audit) PipeAll(table string, pipeline interface{}, result interface{}) error {
+	return a.database.PipeAll(table, pipeline, result)
+}
+
+func (a *audit) Iterate(ctx context.Context, table string, query db.Filter, opts *db.IterOptions, fn func(decode func(v interface{}) error) error) error {
+	return a.database.Iterate(ctx, table, query, opts, fn)
+}
+
+func (a *audit) InsertOne(table string, payload interface{}) (*db.InsertOneResult, error) {
+	result, err := a.database.InsertOne(table, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	a.record("InsertOne", table, db.Filter{"_id": result.InsertedID}, payload, 1)
+	return result, nil
+}
+
+func (a *audit) InsertMany(table string, payload []interface{}) (*db.InsertManyResult, error) {
+	result, err := a.database.InsertMany(table, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := db.Filter{"_id": db.Filter{"$in": result.InsertedIDs}}
+	a.record("InsertMany", table, filter, payload, int64(len(result.InsertedIDs)))
+	return result, nil
+}
+
+func (a *audit) Upsert(table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	result, err := a.database.Upsert(table, selector, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	a.record("Upsert", table, selector, payload, result.MatchedCount+result.UpsertedCount)
+	return result, nil
+}
+
+func (a *audit) UpdateOne(table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	result, err := a.database.UpdateOne(table, selector, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	a.record("UpdateOne", table, selector, payload, result.MatchedCount)
+	return result, nil
+}
+
+func (a *audit) UpdateMany(table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	result, err := a.database.UpdateMany(table, selector, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	a.record("UpdateMany", table, selector, payload, result.MatchedCount)
+	return result, nil
+}
+
+func (a *audit) DeleteOne(table string, selector db.Filter) (*db.DeleteResult, error) {
+	result, err := a.database.DeleteOne(table, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	a.record("DeleteOne", table, selector, nil, result.DeletedCount)
+	return result, nil
+}
+
+func (a *audit) DeleteMany(table string, selector db.Filter) (*db.DeleteResult, error) {
+	result, err := a.database.DeleteMany(table, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	a.record("DeleteMany", table, selector, nil, result.DeletedCount)
+	return result, nil
+}
+
+// BulkWrite audits the operations of the bulk write in one entry, the partial
+// result of a failed bulk write is audited too
+func (a *audit) BulkWrite(table string, ops []db.WriteModel, ordered bool) (*db.BulkWriteResult, error) {
+	result, err := a.database.BulkWrite(table, ops, ordered)
+	if result == nil {
+		return nil, err
+	}
+
+	operations := make([]db.Filter, len(ops))
+	for i, op := range ops {
+		operations[i] = a.bulkOperation(table, op)
+	}
+	affected := result.InsertedCount + result.MatchedCount + result.UpsertedCount + result.DeletedCount
+	a.write(Entry{Operation: "BulkWrite", Collection: table, Payload: encodeJSON(operations), Affected: affected})
+
+	return result, err
+}
+
+// bulkOperation describes the operation of a bulk write, its filter and
+// documents are redacted
+func (a *audit) bulkOperation(table string, op db.WriteModel) db.Filter {
+	redact := func(v interface{}) interface{} {
+		if v == nil {
+			return nil
+		}
+
+		redacted, err := a.redact(table, v)
+		if err != nil {
+			logger.Error("[Audit] Failed to redact ", table, ": ", err)
+			return nil
+		}
+		return redacted
+	}
+
+	switch op := op.(type) {
+	case db.InsertOneModel:
+		return db.Filter{"operation": "InsertOne", "document": redact(op.Document)}
+	case db.UpdateOneModel:
+		return db.Filter{"operation": "UpdateOne", "filter": redact(op.Filter), "update": redact(op.Update), "upsert": op.Upsert}
+	case db.UpdateManyModel:
+		return db.Filter{"operation": "UpdateMany", "filter": redact(op.Filter), "update": redact(op.Update), "upsert": op.Upsert}
+	case db.ReplaceOneModel:
+		return db.Filter{"operation": "ReplaceOne", "filter": redact(op.Filter), "replacement": redact(op.Replacement), "upsert": op.Upsert}
+	case db.DeleteOneModel:
+		return db.Filter{"operation": "DeleteOne", "filter": redact(op.Filter)}
+	case db.DeleteManyModel:
+		return db.Filter{"operation": "DeleteMany", "filter": redact(op.Filter)}
+	default:
+		return db.Filter{"operation": fmt.Sprintf("%T", op)}
+	}
+}
+
+func (a *audit) ApplyDB(table string, selector db.Filter, payload interface{}, result interface{}) error {
+	err := a.database.ApplyDB(table, selector, payload, result)
+	if err != nil {
+		return err
+	}
+
+	a.record("ApplyDB", table, selector, payload, 1)
+	return nil
+}
+
+func (a *audit) Ping(ctx context.Context) error {
+	return a.database.Ping(ctx)
+}
+
+func (a *audit) Close(ctx context.Context) error {
+	return a.database.Close(ctx)
+}
@@ -0,0 +1,249 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+// fakeMongo runs the writes without a server, the audit entries are kept in
+// entries. It implements database.MongoContext, wrap it in plainMongo for a
+// database.Mongo only.
+type fakeMongo struct {
+	db.MongoContext
+	entries []Entry
+	err     error
+	matched int64
+	bulk    *db.BulkWriteResult
+	inTx    bool
+	txOps   []string
+}
+
+type plainMongo struct {
+	db.Mongo
+}
+
+func (f *fakeMongo) InsertOne(table string, payload interface{}) (*db.InsertOneResult, error) {
+	if table == DefaultCollection {
+		if f.inTx {
+			f.txOps = append(f.txOps, "entry")
+		}
+		f.entries = append(f.entries, payload.(Entry))
+		return &db.InsertOneResult{}, nil
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &db.InsertOneResult{InsertedID: "id"}, nil
+}
+
+func (f *fakeMongo) InsertMany(_ string, payload []interface{}) (*db.InsertManyResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &db.InsertManyResult{InsertedIDs: make([]interface{}, len(payload))}, nil
+}
+
+func (f *fakeMongo) Upsert(string, db.Filter, interface{}) (*db.UpdateResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &db.UpdateResult{UpsertedCount: 1}, nil
+}
+
+func (f *fakeMongo) UpdateOne(string, db.Filter, interface{}) (*db.UpdateResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.inTx {
+		f.txOps = append(f.txOps, "update")
+	}
+
+	return &db.UpdateResult{MatchedCount: f.matched}, nil
+}
+
+func (f *fakeMongo) UpdateOneContext(_ context.Context, table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return f.UpdateOne(table, selector, payload)
+}
+
+func (f *fakeMongo) InsertOneContext(_ context.Context, table string, payload interface{}) (*db.InsertOneResult, error) {
+	return f.InsertOne(table, payload)
+}
+
+func (f *fakeMongo) UpdateMany(string, db.Filter, interface{}) (*db.UpdateResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &db.UpdateResult{MatchedCount: f.matched}, nil
+}
+
+func (f *fakeMongo) DeleteOne(string, db.Filter) (*db.DeleteResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &db.DeleteResult{DeletedCount: f.matched}, nil
+}
+
+func (f *fakeMongo) DeleteMany(string, db.Filter) (*db.DeleteResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &db.DeleteResult{DeletedCount: f.matched}, nil
+}
+
+func (f *fakeMongo) BulkWrite(string, []db.WriteModel, bool) (*db.BulkWriteResult, error) {
+	return f.bulk, f.err
+}
+
+func (f *fakeMongo) ApplyDB(string, db.Filter, interface{}, interface{}) error {
+	return f.err
+}
+
+func (f *fakeMongo) WithTransaction(_ context.Context, fn func(tx db.Mongo) error) error {
+	f.inTx = true
+	defer func() { f.inTx = false }()
+
+	return fn(f)
+}
+
+var selector = db.Filter{"code": "dell"}
+
+func TestWrites(t *testing.T) {
+	update := db.Filter{"$set": db.Filter{"name": "Dell"}}
+	tests := []struct {
+		operation string
+		write     func(a Mongo) error
+		affected  int64
+	}{
+		{"InsertOne", func(a Mongo) error { _, err := a.InsertOne("brand", selector); return err }, 1},
+		{"InsertMany", func(a Mongo) error { _, err := a.InsertMany("brand", []interface{}{selector, selector}); return err }, 2},
+		{"Upsert", func(a Mongo) error { _, err := a.Upsert("brand", selector, update); return err }, 1},
+		{"UpdateOne", func(a Mongo) error { _, err := a.UpdateOne("brand", selector, update); return err }, 3},
+		{"UpdateMany", func(a Mongo) error { _, err := a.UpdateMany("brand", selector, update); return err }, 3},
+		{"DeleteOne", func(a Mongo) error { _, err := a.DeleteOne("brand", selector); return err }, 3},
+		{"DeleteMany", func(a Mongo) error { _, err := a.DeleteMany("brand", selector); return err }, 3},
+		{"ApplyDB", func(a Mongo) error { return a.ApplyDB("brand", selector, update, nil) }, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.operation, func(t *testing.T) {
+			database := &fakeMongo{matched: 3}
+			if err := tt.write(New(database)); err != nil {
+				t.Fatalf("%s: %v", tt.operation, err)
+			}
+			if len(database.entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(database.entries))
+			}
+
+			entry := database.entries[0]
+			if entry.Operation != tt.operation || entry.Collection != "brand" || entry.Affected != tt.affected {
+				t.Errorf("entry = %+v, want %s of brand with %d affected", entry, tt.operation, tt.affected)
+			}
+			if entry.Filter == "" || entry.Timestamp.IsZero() {
+				t.Errorf("entry = %+v, want the filter and the timestamp", entry)
+			}
+
+			failed := &fakeMongo{err: errors.New("write failed")}
+			if err := tt.write(New(failed)); err == nil || len(failed.entries) != 0 {
+				t.Errorf("failed %s returned %v with %d entries, want the error without entry", tt.operation, err, len(failed.entries))
+			}
+		})
+	}
+}
+
+func TestBulkWrite(t *testing.T) {
+	ops := []db.WriteModel{
+		db.InsertOneModel{Document: selector},
+		db.InsertOneModel{Document: selector},
+		db.DeleteManyModel{Filter: selector},
+	}
+	bulkErr := &db.BulkWriteError{WriteErrors: []db.WriteError{{Index: 1, Code: 11000}}}
+	database := &fakeMongo{bulk: &db.BulkWriteResult{InsertedCount: 1, DeletedCount: 4}, err: bulkErr}
+
+	_, err := New(database).BulkWrite("brand", ops, false)
+	if err != bulkErr {
+		t.Fatalf("BulkWrite = %v, want the write errors", err)
+	}
+	if len(database.entries) != 1 || database.entries[0].Affected != 5 {
+		t.Fatalf("entries = %+v, want one entry of the partial result with 5 affected", database.entries)
+	}
+
+	failed := &fakeMongo{err: errors.New("connection lost")}
+	if _, err := New(failed).BulkWrite("brand", ops, false); err == nil || len(failed.entries) != 0 {
+		t.Errorf("failed BulkWrite returned %v with %d entries, want the error without entry", err, len(failed.entries))
+	}
+}
+
+func TestAuditCollection(t *testing.T) {
+	database := &fakeMongo{}
+	New(database).InsertOne(DefaultCollection, Entry{Operation: "manual"})
+
+	if len(database.entries) != 1 || database.entries[0].Operation != "manual" {
+		t.Errorf("entries = %+v, want only the inserted entry", database.entries)
+	}
+}
+
+func TestActor(t *testing.T) {
+	ctx := ContextWithActor(context.Background(), "user-1")
+
+	database := &fakeMongo{matched: 1}
+	New(database).WithContext(ctx).UpdateOne("brand", selector, nil)
+	New(database, WithActorFunc(func(context.Context) string { return "system" })).UpdateOne("brand", selector, nil)
+	New(database).UpdateOne("brand", selector, nil)
+
+	want := []string{"user-1", "system", ""}
+	if len(database.entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(database.entries), len(want))
+	}
+	for i, entry := range database.entries {
+		if entry.Actor != want[i] {
+			t.Errorf("actor of entry %d = %q, want %q", i, entry.Actor, want[i])
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := New(plainMongo{&fakeMongo{}}).(db.MongoContext); ok {
+		t.Errorf("wrapper of a database.Mongo implements database.MongoContext")
+	}
+
+	database := &fakeMongo{matched: 1}
+	a, ok := New(database).(db.MongoContext)
+	if !ok {
+		t.Fatal("wrapper of a database.MongoContext does not implement it")
+	}
+	if _, ok := New(database).WithContext(context.Background()).(db.MongoContext); !ok {
+		t.Errorf("WithContext does not implement database.MongoContext")
+	}
+
+	ctx := ContextWithActor(context.Background(), "user-1")
+	if _, err := a.UpdateOneContext(ctx, "brand", selector, nil); err != nil {
+		t.Fatalf("UpdateOneContext: %v", err)
+	}
+	if len(database.entries) != 1 || database.entries[0].Actor != "user-1" {
+		t.Fatalf("entries = %+v, want one entry of user-1", database.entries)
+	}
+
+	err := a.WithTransaction(ctx, func(tx db.Mongo) error {
+		_, err := tx.UpdateOne("brand", selector, nil)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	if want := []string{"update", "entry"}; !reflect.DeepEqual(database.txOps, want) {
+		t.Errorf("operations of the transaction = %q, want %q", database.txOps, want)
+	}
+	if entry := database.entries[1]; entry.Actor != "user-1" {
+		t.Errorf("actor of the transaction entry = %q, want user-1", entry.Actor)
+	}
+}
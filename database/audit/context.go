@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"context"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/utils/paging"
+)
+
+// auditContext is the audit of a database.MongoContext, the context operations
+// run the audited operations on a view of the database bound to their ctx, which
+// also gives the actor
+type auditContext struct {
+	*audit
+	ctxDatabase db.MongoContext
+}
+
+var _ db.MongoContext = (*auditContext)(nil)
+
+func (a *auditContext) view(ctx context.Context) *audit {
+	return &audit{database: db.WithContext(a.ctxDatabase, ctx), opt: a.opt, ctx: ctx}
+}
+
+func (a *auditContext) FindOneContext(ctx context.Context, table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	return a.ctxDatabase.FindOneContext(ctx, table, query, opts, result)
+}
+
+func (a *auditContext) FindManyContext(ctx context.Context, table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	return a.ctxDatabase.FindManyContext(ctx, table, query, opts, result)
+}
+
+func (a *auditContext) FindManyPagingContext(ctx context.Context, table string, query db.Filter, opts *db.FindOptions, offset int, limit int, result interface{}, pagingOpts ...paging.Option) (*paging.Paging, error) {
+	return a.ctxDatabase.FindManyPagingContext(ctx, table, query, opts, offset, limit, result, pagingOpts...)
+}
+
+func (a *auditContext) FindManyCursorContext(ctx context.Context, table string, query db.Filter, sort db.Sort, cursor string, limit int, result interface{}) (*db.Cursor, error) {
+	return a.ctxDatabase.FindManyCursorContext(ctx, table, query, sort, cursor, limit, result)
+}
+
+func (a *auditContext) PipeAllContext(ctx context.Context, table string, pipeline interface{}, result interface{}) error {
+	return a.ctxDatabase.PipeAllContext(ctx, table, pipeline, result)
+}
+
+func (a *auditContext) InsertOneContext(ctx context.Context, table string, payload interface{}) (*db.InsertOneResult, error) {
+	return a.view(ctx).InsertOne(table, payload)
+}
+
+func (a *auditContext) InsertManyContext(ctx context.Context, table string, payload []interface{}) (*db.InsertManyResult, error) {
+	return a.view(ctx).InsertMany(table, payload)
+}
+
+func (a *auditContext) UpsertContext(ctx context.Context, table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return a.view(ctx).Upsert(table, selector, payload)
+}
+
+func (a *auditContext) UpdateOneContext(ctx context.Context, table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return a.view(ctx).UpdateOne(table, selector, payload)
+}
+
+func (a *auditContext) UpdateManyContext(ctx context.Context, table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return a.view(ctx).UpdateMany(table, selector, payload)
+}
+
+func (a *auditContext) DeleteOneContext(ctx context.Context, table string, selector db.Filter) (*db.DeleteResult, error) {
+	return a.view(ctx).DeleteOne(table, selector)
+}
+
+func (a *auditContext) DeleteManyContext(ctx context.Context, table string, selector db.Filter) (*db.DeleteResult, error) {
+	return a.view(ctx).DeleteMany(table, selector)
+}
+
+func (a *auditContext) BulkWriteContext(ctx context.Context, table string, ops []db.WriteModel, ordered bool) (*db.BulkWriteResult, error) {
+	return a.view(ctx).BulkWrite(table, ops, ordered)
+}
+
+func (a *auditContext) ApplyDBContext(ctx context.Context, table string, selector db.Filter, payload interface{}, result interface{}) error {
+	return a.view(ctx).ApplyDB(table, selector, payload, result)
+}
+
+// WithTransaction runs fn in a transaction of the database, the writes of tx
+// and their entries are part of the transaction, so the entries of an aborted
+// transaction are discarded. The actor is the one of ctx.
+func (a *auditContext) WithTransaction(ctx context.Context, fn func(tx db.Mongo) error) error {
+	return a.ctxDatabase.WithTransaction(ctx, func(tx db.Mongo) error {
+		return fn(wrap(tx, a.opt, ctx))
+	})
+}
+
+func (a *auditContext) Watch(ctx context.Context, table string, pipeline interface{}, handler db.ChangeHandler, opts *db.WatchOptions) error {
+	return a.ctxDatabase.Watch(ctx, table, pipeline, handler, opts)
+}
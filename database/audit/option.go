@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+)
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	collection string
+	actor      func(ctx context.Context) string
+	redact     map[string][]string
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithCollection sets the collection of the audit entries, default is
+// audit_logs
+func WithCollection(collection string) Option {
+	return optionFn(func(opt *option) {
+		opt.collection = collection
+	})
+}
+
+// WithActorFunc sets the function which returns the actor of a context, default
+// is ActorFromContext
+func WithActorFunc(fn func(ctx context.Context) string) Option {
+	return optionFn(func(opt *option) {
+		opt.actor = fn
+	})
+}
+
+// WithRedact redacts the fields in the filters and payloads of all collections.
+// A field is a name or a dotted path, e.g. "card.number", which matches at any
+// depth and in arrays, so "password" also redacts "users.0.password". The sub
+// fields of a redacted field are redacted too.
+func WithRedact(fields ...string) Option {
+	return WithCollectionRedact("", fields...)
+}
+
+// WithCollectionRedact redacts the fields in the filters and payloads of the
+// collection
+func WithCollectionRedact(collection string, fields ...string) Option {
+	return optionFn(func(opt *option) {
+		opt.redact[collection] = append(opt.redact[collection], fields...)
+	})
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{
+		collection: DefaultCollection,
+		actor:      ActorFromContext,
+		redact:     map[string][]string{},
+	}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+
+	return &opt
+}
@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+// Redacted replaces the values of the redacted fields
+const Redacted = "[REDACTED]"
+
+// normalize converts v to documents of db.Filter, slices and values with bson,
+// so the fields of structs are named as in the database and can be redacted
+func normalize(v interface{}) (interface{}, error) {
+	data, err := bson.Marshal(bson.D{{Key: "v", Value: v}})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.D
+	err = bson.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return plain(doc[0].Value), nil
+}
+
+// plain converts the bson types of mongo-driver, which mgo does not marshal
+func plain(v interface{}) interface{} {
+	switch v := v.(type) {
+	case primitive.D:
+		doc := make(db.Filter, len(v))
+		for _, e := range v {
+			doc[e.Key] = plain(e.Value)
+		}
+		return doc
+	case primitive.M:
+		doc := make(db.Filter, len(v))
+		for key, value := range v {
+			doc[key] = plain(value)
+		}
+		return doc
+	case primitive.A:
+		values := make([]interface{}, len(v))
+		for i, value := range v {
+			values[i] = plain(value)
+		}
+		return values
+	case primitive.ObjectID:
+		return db.ObjectID(v)
+	case primitive.DateTime:
+		return time.Unix(0, int64(v)*int64(time.Millisecond)).UTC()
+	default:
+		return v
+	}
+}
+
+type redactor []string
+
+// redact replaces the values of the fields of the rules in the normalized value,
+// the operators and array indexes are not part of the paths, so "password"
+// matches {"$set": {"profile": {"password": ...}}} and {"users.0.password": ...}
+func (r redactor) redact(v interface{}, path string) interface{} {
+	switch v := v.(type) {
+	case db.Filter:
+		doc := make(db.Filter, len(v))
+		for key, value := range v {
+			fieldPath := join(path, fieldPathOf(key))
+			if fieldPath != path && r.match(fieldPath) {
+				doc[key] = Redacted
+			} else {
+				doc[key] = r.redact(value, fieldPath)
+			}
+		}
+		return doc
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, value := range v {
+			values[i] = r.redact(value, path)
+		}
+		return values
+	default:
+		return v
+	}
+}
+
+// match reports whether the path contains the segments of a rule, a rule matches
+// the field at any depth and the fields in it
+func (r redactor) match(path string) bool {
+	path = "." + path + "."
+	for _, field := range r {
+		if strings.Contains(path, "."+field+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldPathOf removes the operators and array indexes of a key
+func fieldPathOf(key string) string {
+	var segments []string
+	for _, segment := range strings.Split(key, ".") {
+		if strings.HasPrefix(segment, "$") {
+			continue
+		}
+		if _, err := strconv.Atoi(segment); err == nil {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+
+	return strings.Join(segments, ".")
+}
+
+func join(path string, field string) string {
+	if path == "" {
+		return field
+	}
+	if field == "" {
+		return path
+	}
+
+	return path + "." + field
+}
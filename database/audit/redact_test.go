@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+type card struct {
+	Number string `bson:"number"`
+	Holder string `bson:"holder"`
+}
+
+type user struct {
+	ID       db.ObjectID `bson:"_id"`
+	Name     string      `bson:"name"`
+	Password string      `bson:"password"`
+	Card     card        `bson:"card"`
+}
+
+func TestRedact(t *testing.T) {
+	rules := redactor{"password", "card.number"}
+	tests := []struct {
+		name string
+		v    interface{}
+		want interface{}
+	}{
+		{
+			name: "top level",
+			v:    db.Filter{"name": "quang", "password": "x"},
+			want: db.Filter{"name": "quang", "password": Redacted},
+		},
+		{
+			name: "nested",
+			v:    db.Filter{"profile": db.Filter{"password": "x", "email": "e"}},
+			want: db.Filter{"profile": db.Filter{"password": Redacted, "email": "e"}},
+		},
+		{
+			name: "operator",
+			v:    db.Filter{"$set": db.Filter{"profile": db.Filter{"password": "x"}}},
+			want: db.Filter{"$set": db.Filter{"profile": db.Filter{"password": Redacted}}},
+		},
+		{
+			name: "dotted key",
+			v:    db.Filter{"$set": db.Filter{"profile.password": "x", "card.number": "4111", "card.holder": "h"}},
+			want: db.Filter{"$set": db.Filter{"profile.password": Redacted, "card.number": Redacted, "card.holder": "h"}},
+		},
+		{
+			name: "array index key",
+			v:    db.Filter{"users.0.password": "x", "users.0.name": "n"},
+			want: db.Filter{"users.0.password": Redacted, "users.0.name": "n"},
+		},
+		{
+			name: "array",
+			v:    db.Filter{"users": []interface{}{db.Filter{"password": "x"}, db.Filter{"name": "n"}}},
+			want: db.Filter{"users": []interface{}{db.Filter{"password": Redacted}, db.Filter{"name": "n"}}},
+		},
+		{
+			name: "nested dotted rule",
+			v:    db.Filter{"order": db.Filter{"card": db.Filter{"number": "4111", "holder": "h"}}},
+			want: db.Filter{"order": db.Filter{"card": db.Filter{"number": Redacted, "holder": "h"}}},
+		},
+		{
+			name: "sub fields",
+			v:    db.Filter{"password": db.Filter{"hash": "x", "salt": "y"}, "password.hash": "x"},
+			want: db.Filter{"password": Redacted, "password.hash": Redacted},
+		},
+		{
+			name: "filter operators",
+			v:    db.Filter{"$or": []interface{}{db.Filter{"password": db.Filter{"$in": []interface{}{"x", "y"}}}, db.Filter{"name": "n"}}},
+			want: db.Filter{"$or": []interface{}{db.Filter{"password": Redacted}, db.Filter{"name": "n"}}},
+		},
+		{
+			name: "similar names",
+			v:    db.Filter{"password_hint": "h", "card": db.Filter{"number_type": "visa"}},
+			want: db.Filter{"password_hint": "h", "card": db.Filter{"number_type": "visa"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.redact(tt.v, ""); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("redact = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactStruct(t *testing.T) {
+	id := db.NewObjectID()
+	normalized, err := normalize(db.Filter{"$set": user{ID: id, Name: "quang", Password: "x", Card: card{Number: "4111", Holder: "h"}}})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+
+	want := db.Filter{"$set": db.Filter{
+		"_id":      id,
+		"name":     "quang",
+		"password": Redacted,
+		"card":     db.Filter{"number": Redacted, "holder": "h"},
+	}}
+	if got := (redactor{"password", "card.number"}).redact(normalized, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("redact = %v, want %v", got, want)
+	}
+}
+
+func TestNormalizeTime(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	normalized, err := normalize(db.Filter{"at": now})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+
+	if got := normalized.(db.Filter)["at"]; !reflect.DeepEqual(got, now) {
+		t.Errorf("normalize time = %v, want %v", got, now)
+	}
+}
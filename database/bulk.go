@@ -0,0 +1,97 @@
+package database
+
+import (
+	"fmt"
+)
+
+// BulkChunkSize is the maximum number of operations sent to the server at once
+// by BulkWrite, bigger batches are split
+const BulkChunkSize = 1000
+
+// WriteModel is an operation of BulkWrite: InsertOneModel, UpdateOneModel,
+// UpdateManyModel, ReplaceOneModel, DeleteOneModel or DeleteManyModel.
+type WriteModel interface {
+	writeModel()
+}
+
+// InsertOneModel inserts the document
+type InsertOneModel struct {
+	Document interface{}
+}
+
+// UpdateOneModel updates the first document matching the filter, the document
+// is inserted if none matches and Upsert is true
+type UpdateOneModel struct {
+	Filter Filter
+	Update interface{}
+	Upsert bool
+}
+
+// UpdateManyModel updates all documents matching the filter
+type UpdateManyModel struct {
+	Filter Filter
+	Update interface{}
+	Upsert bool
+}
+
+// ReplaceOneModel replaces the first document matching the filter
+type ReplaceOneModel struct {
+	Filter      Filter
+	Replacement interface{}
+	Upsert      bool
+}
+
+// DeleteOneModel deletes the first document matching the filter
+type DeleteOneModel struct {
+	Filter Filter
+}
+
+// DeleteManyModel deletes all documents matching the filter
+type DeleteManyModel struct {
+	Filter Filter
+}
+
+func (InsertOneModel) writeModel()  {}
+func (UpdateOneModel) writeModel()  {}
+func (UpdateManyModel) writeModel() {}
+func (ReplaceOneModel) writeModel() {}
+func (DeleteOneModel) writeModel()  {}
+func (DeleteManyModel) writeModel() {}
+
+// BulkWriteResult is the result of BulkWrite, UpsertedIDs are by index of the
+// operation
+type BulkWriteResult struct {
+	InsertedCount int64
+	MatchedCount  int64
+	ModifiedCount int64
+	DeletedCount  int64
+	UpsertedCount int64
+	UpsertedIDs   map[int]interface{}
+}
+
+// WriteError is the error of an operation of BulkWrite, Index is the index of
+// the operation
+type WriteError struct {
+	Index   int
+	Code    int
+	Message string
+}
+
+func (e WriteError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Message)
+}
+
+// BulkWriteError is returned by BulkWrite when operations failed, in ordered
+// mode only the first failed operation is reported because the next ones are
+// not run.
+type BulkWriteError struct {
+	WriteErrors []WriteError
+}
+
+func (e *BulkWriteError) Error() string {
+	if len(e.WriteErrors) == 1 {
+		return "bulk write failed, " + e.WriteErrors[0].Error()
+	}
+
+	return fmt.Sprintf("bulk write failed, %d operations failed, first %s", len(e.WriteErrors), e.WriteErrors[0].Error())
+}
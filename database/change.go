@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoFullDocument is returned by ChangeEvent.Decode when the event has no full
+// document, e.g. a delete or an update watched without full document lookup
+var ErrNoFullDocument = errors.New("change event has no full document")
+
+// ChangeEvent is a change of a document in a watched collection
+type ChangeEvent struct {
+	// Operation is insert, update, replace, delete, drop, rename or invalidate
+	Operation     string
+	Collection    string
+	DocumentKey   Filter
+	UpdatedFields Filter
+	RemovedFields []string
+	ClusterTime   time.Time
+	// FullDocument is the raw BSON document, see Decode
+	FullDocument []byte
+	// Token is the resume token of the event
+	Token []byte
+	// Decode unmarshals the full document into v with the bson of the backend,
+	// it returns ErrNoFullDocument if the event has none
+	Decode func(v interface{}) error
+}
+
+// ChangeHandler handles a change event, the watch stops with the returned
+// error and the event is delivered again when the watch is resumed.
+type ChangeHandler func(ctx context.Context, event *ChangeEvent) error
+
+// TokenStore persists the resume token of a named watch, so the watch resumes
+// after the last handled event when it is restarted. Load returns nil if no
+// token is stored.
+type TokenStore interface {
+	Load(name string) ([]byte, error)
+	Save(name string, token []byte) error
+}
+
+// WatchOptions are the options of Watch
+type WatchOptions struct {
+	// Name identifies the watch in Store, it is required with a store
+	Name  string
+	Store TokenStore
+	// FullDocument looks up the current version of the document of update
+	// events, insert and replace events always have it
+	FullDocument bool
+	BatchSize    int
+	MaxAwaitTime time.Duration
+}
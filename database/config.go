@@ -2,17 +2,52 @@ package database
 
 import "time"
 
+// Config is the connection config of mongodb, the zero values are the defaults
+// of the driver. The credentials may contain any character, they are escaped.
 type Config struct {
-	Hosts             string
-	AuthDatabase      string
-	Database          string
-	AuthUserName      string
-	AuthPassword      string
+	// Hosts is the comma separated list of host:port, or the DNS name of the
+	// cluster with SRV
+	Hosts        string
+	AuthDatabase string
+	Database     string
+	AuthUserName string
+	AuthPassword string
+	// ConnectionTimeout is in seconds, default is 10
 	ConnectionTimeout int
 	Env               string
-	Replica           string
+	// Replica is the name of the replica set, it is used when Env is "replica"
+	Replica string
+
+	// SRV looks up the hosts and options of the cluster from the DNS records of
+	// Hosts, as a mongodb+srv:// URI does. TLS is enabled with SRV.
+	SRV bool
+	// TLS enables TLS, the server certificate is verified with the CA of
+	// TLSCAFile or the CAs of the system
+	TLS                   bool
+	TLSCAFile             string
+	TLSCertificateKeyFile string
+	TLSInsecure           bool
+
+	// ReadPreference is primary, primaryPreferred, secondary,
+	// secondaryPreferred or nearest
+	ReadPreference string
+	// WriteConcern is "majority", a number of nodes, e.g. "1", or a tag set name
+	WriteConcern string
+
+	MinPoolSize int
+	MaxPoolSize int
+	AppName     string
+	// Compressors are the compressions of the messages in order of preference:
+	// snappy, zlib or zstd
+	Compressors []string
+	// DisableRetryWrites disables the retry of writes, which is enabled by default
+	// by mongo-driver. mgo does not retry writes.
+	DisableRetryWrites bool
 }
 
+// IndexConfig describes an index, the keys are field names which may be prefixed
+// by - (minus) for descending order, or by "$<kind>:" for special indexes, e.g.
+// "$text:name", "$2dsphere:location" or "$hashed:code".
 type IndexConfig struct {
 	Key              []string
 	Unique           bool
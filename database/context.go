@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+
+	"github.com/quangdangfit/gosdk/utils/paging"
+)
+
+// WithContext returns a view of the database whose operations run with ctx, the
+// wrappers of Mongo use it to implement the context-first operations of
+// MongoContext with their Mongo operations.
+func WithContext(database MongoContext, ctx context.Context) Mongo {
+	return &contextView{MongoContext: database, ctx: ctx}
+}
+
+type contextView struct {
+	MongoContext
+	ctx context.Context
+}
+
+func (v *contextView) FindOne(table string, query Filter, opts *FindOptions, result interface{}) error {
+	return v.FindOneContext(v.ctx, table, query, opts, result)
+}
+
+func (v *contextView) FindMany(table string, query Filter, opts *FindOptions, result interface{}) error {
+	return v.FindManyContext(v.ctx, table, query, opts, result)
+}
+
+func (v *contextView) FindManyPaging(table string, query Filter, opts *FindOptions, offset int, limit int, result interface{}, pagingOpts ...paging.Option) (*paging.Paging, error) {
+	return v.FindManyPagingContext(v.ctx, table, query, opts, offset, limit, result, pagingOpts...)
+}
+
+func (v *contextView) FindManyCursor(table string, query Filter, sort Sort, cursor string, limit int, result interface{}) (*Cursor, error) {
+	return v.FindManyCursorContext(v.ctx, table, query, sort, cursor, limit, result)
+}
+
+func (v *contextView) PipeAll(table string, pipeline interface{}, result interface{}) error {
+	return v.PipeAllContext(v.ctx, table, pipeline, result)
+}
+
+func (v *contextView) InsertOne(table string, payload interface{}) (*InsertOneResult, error) {
+	return v.InsertOneContext(v.ctx, table, payload)
+}
+
+func (v *contextView) InsertMany(table string, payload []interface{}) (*InsertManyResult, error) {
+	return v.InsertManyContext(v.ctx, table, payload)
+}
+
+func (v *contextView) Upsert(table string, selector Filter, payload interface{}) (*UpdateResult, error) {
+	return v.UpsertContext(v.ctx, table, selector, payload)
+}
+
+func (v *contextView) UpdateOne(table string, selector Filter, payload interface{}) (*UpdateResult, error) {
+	return v.UpdateOneContext(v.ctx, table, selector, payload)
+}
+
+func (v *contextView) UpdateMany(table string, selector Filter, payload interface{}) (*UpdateResult, error) {
+	return v.UpdateManyContext(v.ctx, table, selector, payload)
+}
+
+func (v *contextView) DeleteOne(table string, selector Filter) (*DeleteResult, error) {
+	return v.DeleteOneContext(v.ctx, table, selector)
+}
+
+func (v *contextView) DeleteMany(table string, selector Filter) (*DeleteResult, error) {
+	return v.DeleteManyContext(v.ctx, table, selector)
+}
+
+func (v *contextView) BulkWrite(table string, ops []WriteModel, ordered bool) (*BulkWriteResult, error) {
+	return v.BulkWriteContext(v.ctx, table, ops, ordered)
+}
+
+func (v *contextView) ApplyDB(table string, selector Filter, payload interface{}, result interface{}) error {
+	return v.ApplyDBContext(v.ctx, table, selector, payload, result)
+}
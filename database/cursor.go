@@ -0,0 +1,28 @@
+package database
+
+import (
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+// ErrInvalidCursor is returned when a continuation token is malformed or was
+// made for another sort
+var ErrInvalidCursor = errors.BadRequest.New("invalid cursor token")
+
+// Cursor holds the continuation tokens of a keyset paginated page. Next reads
+// the page after and Prev the page before, a token is empty when there is no
+// such page. The tokens are opaque, they encode the sort keys and _id of the
+// last or first document of the page.
+type Cursor struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// HasNext reports whether there is a page after
+func (c *Cursor) HasNext() bool {
+	return c.Next != ""
+}
+
+// HasPrev reports whether there is a page before
+func (c *Cursor) HasPrev() bool {
+	return c.Prev != ""
+}
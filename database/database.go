@@ -1,24 +1,74 @@
 package database
 
 import (
-	"gopkg.in/mgo.v2"
+	"context"
 
 	"github.com/quangdangfit/gosdk/utils/paging"
 )
 
+// Filter is a query or selector document, values may be nested filters, e.g.
+// Filter{"age": Filter{"$gte": 18}}. It is translated by each backend, so the
+// callers do not depend on the bson types of a driver.
+type Filter map[string]interface{}
+
+// Sort is the list of field names to sort by, a field name may be prefixed by
+// - (minus) for it to be sorted in reverse order, e.g. Sort{"-created_at", "name"}.
+type Sort []string
+
 type Mongo interface {
-	EnsureIndex(collectionName string, index mgo.Index) bool
+	EnsureIndex(collectionName string, index IndexConfig) bool
 	DropIndex(collectionName string, name string) bool
-	FindOne(table string, query map[string]interface{}, sort string, result interface{}) (err error)
-	FindMany(table string, query map[string]interface{}, sort string, result interface{}) (err error)
-	FindManyPaging(table string, query map[string]interface{}, sort string, offset int, limit int, result interface{}) (*paging.Paging, error)
+	ListIndexes(collectionName string) ([]IndexConfig, error)
+	// DropCollection drops the collection with its indexes, a missing collection
+	// is not an error
+	DropCollection(collectionName string) error
+	FindOne(table string, query Filter, opts *FindOptions, result interface{}) (err error)
+	FindMany(table string, query Filter, opts *FindOptions, result interface{}) (err error)
+	FindManyPaging(table string, query Filter, opts *FindOptions, offset int, limit int, result interface{}, pagingOpts ...paging.Option) (*paging.Paging, error)
+	FindManyCursor(table string, query Filter, sort Sort, cursor string, limit int, result interface{}) (*Cursor, error)
 	PipeAll(table string, pipeline interface{}, result interface{}) (err error)
-	InsertOne(table string, payload interface{}) (err error)
-	InsertMany(table string, payload []interface{}) (err error)
-	Upsert(table string, selector interface{}, payload interface{}) (err error)
-	UpdateOne(table string, selector interface{}, payload interface{}) (err error)
-	UpdateMany(table string, selector interface{}, payload interface{}) (err error)
-	DeleteOne(table string, selector interface{}) (err error)
-	DeleteMany(table string, selector interface{}) (err error)
-	ApplyDB(table string, selector interface{}, payload interface{}, result interface{}) (err error)
+	Iterate(ctx context.Context, table string, query Filter, opts *IterOptions, fn func(decode func(v interface{}) error) error) error
+	InsertOne(table string, payload interface{}) (*InsertOneResult, error)
+	InsertMany(table string, payload []interface{}) (*InsertManyResult, error)
+	Upsert(table string, selector Filter, payload interface{}) (*UpdateResult, error)
+	UpdateOne(table string, selector Filter, payload interface{}) (*UpdateResult, error)
+	UpdateMany(table string, selector Filter, payload interface{}) (*UpdateResult, error)
+	DeleteOne(table string, selector Filter) (*DeleteResult, error)
+	DeleteMany(table string, selector Filter) (*DeleteResult, error)
+	BulkWrite(table string, ops []WriteModel, ordered bool) (*BulkWriteResult, error)
+	ApplyDB(table string, selector Filter, payload interface{}, result interface{}) (err error)
+
+	// Ping checks the connection to the server
+	Ping(ctx context.Context) error
+	// Close closes the connection, the database cannot be used after
+	Close(ctx context.Context) error
+}
+
+// MongoContext extends Mongo with context-first versions of the operations, the
+// deadline and cancellation of ctx are applied to each operation.
+type MongoContext interface {
+	Mongo
+
+	FindOneContext(ctx context.Context, table string, query Filter, opts *FindOptions, result interface{}) (err error)
+	FindManyContext(ctx context.Context, table string, query Filter, opts *FindOptions, result interface{}) (err error)
+	FindManyPagingContext(ctx context.Context, table string, query Filter, opts *FindOptions, offset int, limit int, result interface{}, pagingOpts ...paging.Option) (*paging.Paging, error)
+	FindManyCursorContext(ctx context.Context, table string, query Filter, sort Sort, cursor string, limit int, result interface{}) (*Cursor, error)
+	PipeAllContext(ctx context.Context, table string, pipeline interface{}, result interface{}) (err error)
+	InsertOneContext(ctx context.Context, table string, payload interface{}) (*InsertOneResult, error)
+	InsertManyContext(ctx context.Context, table string, payload []interface{}) (*InsertManyResult, error)
+	UpsertContext(ctx context.Context, table string, selector Filter, payload interface{}) (*UpdateResult, error)
+	UpdateOneContext(ctx context.Context, table string, selector Filter, payload interface{}) (*UpdateResult, error)
+	UpdateManyContext(ctx context.Context, table string, selector Filter, payload interface{}) (*UpdateResult, error)
+	DeleteOneContext(ctx context.Context, table string, selector Filter) (*DeleteResult, error)
+	DeleteManyContext(ctx context.Context, table string, selector Filter) (*DeleteResult, error)
+	BulkWriteContext(ctx context.Context, table string, ops []WriteModel, ordered bool) (*BulkWriteResult, error)
+	ApplyDBContext(ctx context.Context, table string, selector Filter, payload interface{}, result interface{}) (err error)
+
+	// WithTransaction runs fn in a multi-document transaction, which is committed
+	// if fn returns nil and aborted otherwise. The operations must be made with tx.
+	WithTransaction(ctx context.Context, fn func(tx Mongo) error) error
+
+	// Watch calls handler for each change of the collection matching the
+	// aggregation pipeline, until ctx is done or handler returns an error.
+	Watch(ctx context.Context, table string, pipeline interface{}, handler ChangeHandler, opts *WatchOptions) error
 }
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"time"
+)
+
+// FindOptions are the options of the find methods, nil is no options. Skip and
+// Limit are ignored by FindManyPaging, they are set by the page.
+type FindOptions struct {
+	// Sort is the list of field names to sort by, see Sort
+	Sort Sort
+	// Projection includes (1) or excludes (0) fields of the results
+	Projection Filter
+	Skip       int
+	Limit      int
+	// Hint is the keys of the index to use, in the format of Sort
+	Hint []string
+	// Collation is not supported by mgo, its find methods return a BadRequest
+	// error with a collation
+	Collation *Collation
+	// MaxTime is the maximum execution time of the query on the server
+	MaxTime time.Duration
+}
+
+// Collation is the language specific rules to compare strings
+type Collation struct {
+	Locale          string
+	CaseLevel       bool
+	CaseFirst       string
+	Strength        int
+	NumericOrdering bool
+	Alternate       string
+	MaxVariable     string
+	Normalization   bool
+	Backwards       bool
+}
@@ -0,0 +1,330 @@
+package index
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/utils/logger"
+)
+
+const (
+	idIndexName = "_id_"
+
+	defaultLanguage         = "english"
+	defaultLanguageOverride = "language"
+)
+
+type Action string
+
+const (
+	Create Action = "create"
+	Drop   Action = "drop"
+	// Recreate drops the existing index then creates the declared one, the
+	// server does not allow both as they have the same name or keys, so the
+	// collection is without the index in between
+	Recreate Action = "recreate"
+)
+
+// Change is a change of an index in a collection, Reason describes the option
+// changes of a recreated index
+type Change struct {
+	Collection string
+	Action     Action
+	Index      db.IndexConfig
+	Existing   *db.IndexConfig
+	Reason     string
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s.%s", c.Action, c.Collection, c.Index.Name)
+	if c.Reason != "" {
+		s += " (" + c.Reason + ")"
+	}
+	return s
+}
+
+// Plan is the list of changes to make the indexes match the declared ones
+type Plan []Change
+
+func (p Plan) String() string {
+	if len(p) == 0 {
+		return "indexes are up to date"
+	}
+
+	lines := make([]string, len(p))
+	for i, c := range p {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Manager keeps the indexes of collections in sync with the declared index
+// configs: missing indexes are created, indexes with changed options are dropped
+// and created again and, optionally, unknown indexes are dropped.
+type Manager struct {
+	database db.Mongo
+	opt      *option
+}
+
+func NewManager(database db.Mongo, opts ...Option) *Manager {
+	return &Manager{
+		database: database,
+		opt:      getOption(opts...),
+	}
+}
+
+// Plan diffs the declared indexes of each collection against the existing ones
+// and returns the changes without applying them.
+func (m *Manager) Plan(specs map[string][]db.IndexConfig) (Plan, error) {
+	collections := make([]string, 0, len(specs))
+	for collection := range specs {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+
+	var plan Plan
+	for _, collection := range collections {
+		existing, err := m.database.ListIndexes(collection)
+		if err != nil {
+			return nil, err
+		}
+
+		plan = append(plan, m.diff(collection, specs[collection], existing)...)
+	}
+
+	return plan, nil
+}
+
+// Apply makes the changes of the plan, in dry-run mode the plan is only
+// reported. The returned plan contains the applied changes, a recreate whose
+// index was dropped but not created again is returned as a drop.
+func (m *Manager) Apply(specs map[string][]db.IndexConfig) (Plan, error) {
+	plan, err := m.Plan(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.opt.dryRun {
+		logger.Info("[Index] Dry run, plan:\n", plan)
+		return plan, nil
+	}
+
+	for i, c := range plan {
+		logger.Info("[Index] ", c)
+
+		switch c.Action {
+		case Create:
+			err = m.create(c.Collection, c.Index)
+		case Drop:
+			err = m.drop(c.Collection, c.Index.Name)
+		case Recreate:
+			err = m.drop(c.Collection, c.Existing.Name)
+			if err != nil {
+				break
+			}
+			err = m.create(c.Collection, c.Index)
+			if err != nil {
+				dropped := Change{Collection: c.Collection, Action: Drop, Index: *c.Existing, Reason: "not recreated"}
+				return append(plan[:i:i], dropped), fmt.Errorf("index %s of %s was dropped but not recreated: %w", c.Existing.Name, c.Collection, err)
+			}
+		}
+		if err != nil {
+			return plan[:i], err
+		}
+	}
+
+	return plan, nil
+}
+
+func (m *Manager) create(collection string, index db.IndexConfig) error {
+	if !m.database.EnsureIndex(collection, index) {
+		return fmt.Errorf("failed to create index %s of %s", index.Name, collection)
+	}
+	return nil
+}
+
+func (m *Manager) drop(collection string, name string) error {
+	if !m.database.DropIndex(collection, name) {
+		return fmt.Errorf("failed to drop index %s of %s", name, collection)
+	}
+	return nil
+}
+
+func (m *Manager) diff(collection string, declared []db.IndexConfig, existing []db.IndexConfig) Plan {
+	var plan Plan
+	matched := make(map[string]bool)
+
+	for _, index := range declared {
+		if index.Name == "" {
+			index.Name = Name(index.Key)
+		}
+
+		current := find(existing, index)
+		if current == nil {
+			plan = append(plan, Change{Collection: collection, Action: Create, Index: index})
+			continue
+		}
+		matched[current.Name] = true
+
+		if reasons := compare(*current, index); len(reasons) > 0 {
+			plan = append(plan, Change{
+				Collection: collection,
+				Action:     Recreate,
+				Index:      index,
+				Existing:   current,
+				Reason:     strings.Join(reasons, ", "),
+			})
+		}
+	}
+
+	if m.opt.dropUnknown {
+		for i := range existing {
+			if existing[i].Name == idIndexName || matched[existing[i].Name] {
+				continue
+			}
+			plan = append(plan, Change{Collection: collection, Action: Drop, Index: existing[i]})
+		}
+	}
+
+	return plan
+}
+
+// Name returns the default name of an index with the keys, which is the name
+// given by the server, e.g. "code_1_created_at_-1"
+func Name(keys []string) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		field, kind := parseKey(key)
+		parts = append(parts, field+"_"+kind)
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// parseKey returns the field name and the kind of key, the kind is 1 or -1 for
+// ordered keys
+func parseKey(key string) (string, string) {
+	switch {
+	case strings.HasPrefix(key, "$"):
+		if c := strings.Index(key, ":"); c > 1 {
+			return key[c+1:], key[1:c]
+		}
+	case strings.HasPrefix(key, "@"):
+		return key[1:], "2d"
+	case strings.HasPrefix(key, "-"):
+		return key[1:], "-1"
+	}
+
+	return strings.TrimPrefix(key, "+"), "1"
+}
+
+// find returns the existing index with the same name or the same keys
+func find(existing []db.IndexConfig, index db.IndexConfig) *db.IndexConfig {
+	for i := range existing {
+		if existing[i].Name == index.Name {
+			return &existing[i]
+		}
+	}
+
+	for i := range existing {
+		if sameKeys(existing[i].Key, index.Key) {
+			return &existing[i]
+		}
+	}
+
+	return nil
+}
+
+// sameKeys compares keys in order, except text keys which compare as a set
+// because the server does not keep their order
+func sameKeys(a, b []string) bool {
+	normalize := func(keys []string) []string {
+		var ordered, text []string
+		for _, key := range keys {
+			if _, kind := parseKey(key); kind == "text" {
+				text = append(text, key)
+			} else {
+				ordered = append(ordered, strings.TrimPrefix(key, "+"))
+			}
+		}
+		sort.Strings(text)
+		return append(ordered, text...)
+	}
+
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// compare returns the differences of options which require to recreate the index
+func compare(current, index db.IndexConfig) []string {
+	var reasons []string
+	add := func(name string, from, to interface{}) {
+		reasons = append(reasons, fmt.Sprintf("%s: %v -> %v", name, from, to))
+	}
+
+	if !sameKeys(current.Key, index.Key) {
+		add("key", current.Key, index.Key)
+	}
+	if current.Unique != index.Unique {
+		add("unique", current.Unique, index.Unique)
+	}
+	if current.Sparse != index.Sparse {
+		add("sparse", current.Sparse, index.Sparse)
+	}
+	if current.ExpireAfter != index.ExpireAfter {
+		add("expire after", current.ExpireAfter, index.ExpireAfter)
+	}
+	if current.Bits != index.Bits {
+		add("bits", current.Bits, index.Bits)
+	}
+	if current.BucketSize != index.BucketSize {
+		add("bucket size", current.BucketSize, index.BucketSize)
+	}
+
+	if isText(index.Key) {
+		if from, to := weights(current), weights(index); !reflect.DeepEqual(from, to) {
+			add("weights", from, to)
+		}
+		if from, to := orDefault(current.DefaultLanguage, defaultLanguage), orDefault(index.DefaultLanguage, defaultLanguage); from != to {
+			add("default language", from, to)
+		}
+		if from, to := orDefault(current.LanguageOverride, defaultLanguageOverride), orDefault(index.LanguageOverride, defaultLanguageOverride); from != to {
+			add("language override", from, to)
+		}
+	}
+
+	return reasons
+}
+
+func isText(keys []string) bool {
+	for _, key := range keys {
+		if _, kind := parseKey(key); kind == "text" {
+			return true
+		}
+	}
+	return false
+}
+
+// weights returns the weights of text fields, the default weight is 1
+func weights(index db.IndexConfig) map[string]int {
+	result := make(map[string]int)
+	for _, key := range index.Key {
+		if field, kind := parseKey(key); kind == "text" {
+			result[field] = 1
+		}
+	}
+	for field, weight := range index.Weights {
+		result[field] = weight
+	}
+
+	return result
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,185 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"code"}, "code_1"},
+		{[]string{"+code", "-created_at"}, "code_1_created_at_-1"},
+		{[]string{"@location"}, "location_2d"},
+		{[]string{"$text:name", "$text:description"}, "name_text_description_text"},
+		{[]string{"$2dsphere:location"}, "location_2dsphere"},
+	}
+
+	for _, tt := range tests {
+		if got := Name(tt.keys); got != tt.want {
+			t.Errorf("Name(%v) = %s, want %s", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		current db.IndexConfig
+		index   db.IndexConfig
+		want    []string
+	}{
+		{
+			name:    "same",
+			current: db.IndexConfig{Key: []string{"code"}, Unique: true},
+			index:   db.IndexConfig{Key: []string{"+code"}, Unique: true},
+		},
+		{
+			name:    "options",
+			current: db.IndexConfig{Key: []string{"code"}},
+			index:   db.IndexConfig{Key: []string{"code"}, Unique: true, ExpireAfter: time.Hour},
+			want:    []string{"unique: false -> true", "expire after: 0s -> 1h0m0s"},
+		},
+		{
+			name:    "keys",
+			current: db.IndexConfig{Key: []string{"code", "-rank"}},
+			index:   db.IndexConfig{Key: []string{"code", "rank"}},
+			want:    []string{"key: [code -rank] -> [code rank]"},
+		},
+		{
+			name:    "text keys in any order",
+			current: db.IndexConfig{Key: []string{"$text:b", "$text:a"}},
+			index:   db.IndexConfig{Key: []string{"$text:a", "$text:b"}, DefaultLanguage: defaultLanguage},
+		},
+		{
+			name:    "text weights",
+			current: db.IndexConfig{Key: []string{"$text:a"}},
+			index:   db.IndexConfig{Key: []string{"$text:a"}, Weights: map[string]int{"a": 2}},
+			want:    []string{"weights: map[a:1] -> map[a:2]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compare(tt.current, tt.index); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compare = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	existing := []db.IndexConfig{
+		{Name: "_id_", Key: []string{"_id"}},
+		{Name: "code_1", Key: []string{"code"}},
+		{Name: "rank_1", Key: []string{"rank"}},
+		{Name: "by_name", Key: []string{"name"}},
+		{Name: "old", Key: []string{"old"}},
+	}
+	declared := []db.IndexConfig{
+		{Key: []string{"code"}, Unique: true},
+		{Name: "rank_1", Key: []string{"-rank"}},
+		{Key: []string{"name"}},
+		{Name: "brand", Key: []string{"brand", "code"}},
+	}
+
+	m := NewManager(nil, WithDropUnknown())
+	plan := m.diff("brands", declared, existing)
+
+	want := []string{
+		"recreate brands.code_1 (unique: false -> true)",
+		"recreate brands.rank_1 (key: [rank] -> [-rank])",
+		"create brands.brand",
+		"drop brands.old",
+	}
+	if got := planStrings(plan); !reflect.DeepEqual(got, want) {
+		t.Fatalf("diff = %q, want %q", got, want)
+	}
+}
+
+type fakeMongo struct {
+	db.Mongo
+	indexes []db.IndexConfig
+	failed  string
+	calls   []string
+}
+
+func (f *fakeMongo) ListIndexes(string) ([]db.IndexConfig, error) {
+	return f.indexes, nil
+}
+
+func (f *fakeMongo) EnsureIndex(_ string, index db.IndexConfig) bool {
+	f.calls = append(f.calls, "create "+index.Name)
+	return index.Name != f.failed
+}
+
+func (f *fakeMongo) DropIndex(_ string, name string) bool {
+	f.calls = append(f.calls, "drop "+name)
+	return true
+}
+
+func TestApplyRecreate(t *testing.T) {
+	specs := map[string][]db.IndexConfig{
+		"brands": {
+			{Name: "code", Key: []string{"code"}, Unique: true},
+			{Name: "rank_desc", Key: []string{"-rank"}},
+		},
+	}
+	database := &fakeMongo{indexes: []db.IndexConfig{{Name: "code", Key: []string{"code"}}}}
+
+	plan, err := NewManager(database).Apply(specs)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(plan) != 2 {
+		t.Fatalf("Apply = %q, want 2 changes", planStrings(plan))
+	}
+
+	want := []string{"drop code", "create code", "create rank_desc"}
+	if !reflect.DeepEqual(database.calls, want) {
+		t.Errorf("calls = %q, want %q", database.calls, want)
+	}
+}
+
+func TestApplyRecreateFailed(t *testing.T) {
+	specs := map[string][]db.IndexConfig{
+		"brands": {{Name: "code", Key: []string{"code"}, Unique: true}},
+	}
+	database := &fakeMongo{indexes: []db.IndexConfig{{Name: "code", Key: []string{"code"}}}, failed: "code"}
+
+	plan, err := NewManager(database).Apply(specs)
+	if err == nil {
+		t.Fatal("Apply succeeded, want an error")
+	}
+
+	want := []string{"drop brands.code (not recreated)"}
+	if got := planStrings(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply = %q, want %q", got, want)
+	}
+}
+
+func TestApplyDryRun(t *testing.T) {
+	specs := map[string][]db.IndexConfig{"brands": {{Key: []string{"code"}}}}
+	database := &fakeMongo{}
+
+	plan, err := NewManager(database, WithDryRun()).Apply(specs)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(plan) != 1 || len(database.calls) != 0 {
+		t.Errorf("Apply = %q with calls %q, want 1 change and no call", planStrings(plan), database.calls)
+	}
+}
+
+func planStrings(plan Plan) []string {
+	lines := make([]string, len(plan))
+	for i, c := range plan {
+		lines[i] = c.String()
+	}
+	return lines
+}
@@ -0,0 +1,41 @@
+package index
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	dropUnknown bool
+	dryRun      bool
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithDropUnknown drops the existing indexes which are not declared, the _id
+// index is never dropped
+func WithDropUnknown() Option {
+	return optionFn(func(opt *option) {
+		opt.dropUnknown = true
+	})
+}
+
+// WithDryRun only reports the plan of Apply without changing any index
+func WithDryRun() Option {
+	return optionFn(func(opt *option) {
+		opt.dryRun = true
+	})
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+
+	return &opt
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"errors"
+)
+
+// ErrStopIteration may be returned by the function of Iterate to stop the
+// iteration early, Iterate then returns nil
+var ErrStopIteration = errors.New("stop iteration")
+
+// IterOptions are the options of Iterate, zero values are the defaults of the
+// backend
+type IterOptions struct {
+	FindOptions
+	BatchSize int
+}
@@ -0,0 +1,310 @@
+package migrate
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/utils/logger"
+)
+
+const lockID = "lock"
+
+var (
+	// ErrLocked is returned when another replica is running the migrations
+	ErrLocked = errors.New("migrations are locked by another process")
+	// ErrNotRecorded is returned when a migration ran but its record could not be
+	// saved or removed, the record must be fixed by hand before migrating again
+	ErrNotRecorded = errors.New("migration ran but was not recorded")
+)
+
+// Func migrates the database up or down
+type Func func(database db.Mongo) error
+
+type Migration struct {
+	Version     int64
+	Description string
+	Up          Func
+	Down        Func
+}
+
+// Status is the state of a registered or applied migration
+type Status struct {
+	Version     int64
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+}
+
+type record struct {
+	Version     int64     `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+type lock struct {
+	ID        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Migrator runs the registered migrations in order of version and records the
+// applied versions in a collection. A lock is held while migrating, so only one
+// replica migrates at a time.
+type Migrator struct {
+	database   db.Mongo
+	opt        *option
+	owner      string
+	migrations map[int64]Migration
+}
+
+func New(database db.Mongo, opts ...Option) *Migrator {
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	return &Migrator{
+		database:   database,
+		opt:        getOption(opts...),
+		owner:      hex.EncodeToString(b),
+		migrations: make(map[int64]Migration),
+	}
+}
+
+// Register adds a migration with the version, down may be nil if the migration
+// cannot be rolled back. It panics if up is nil or the version is registered
+// twice.
+func (m *Migrator) Register(version int64, description string, up, down Func) {
+	if up == nil {
+		panic(fmt.Sprintf("migrate: version %d has no up migration", version))
+	}
+	if _, ok := m.migrations[version]; ok {
+		panic(fmt.Sprintf("migrate: version %d is registered twice", version))
+	}
+
+	m.migrations[version] = Migration{
+		Version:     version,
+		Description: description,
+		Up:          up,
+		Down:        down,
+	}
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	versions := m.versions()
+	if len(versions) == 0 {
+		return nil
+	}
+
+	return m.To(versions[len(versions)-1])
+}
+
+// To migrates the database to the version, pending migrations up to the version
+// are applied and applied migrations above the version are rolled back.
+func (m *Migrator) To(version int64) error {
+	return m.locked(func(applied map[int64]record) error {
+		for _, v := range m.versions() {
+			if v <= version && !isApplied(applied, v) {
+				if err := m.up(m.migrations[v]); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, v := range appliedVersions(applied) {
+			if v > version {
+				if err := m.down(v); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// Rollback rolls back the last steps applied migrations.
+func (m *Migrator) Rollback(steps int) error {
+	return m.locked(func(applied map[int64]record) error {
+		for i, v := range appliedVersions(applied) {
+			if i >= steps {
+				break
+			}
+			if err := m.down(v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Status lists the registered and applied migrations in order of version.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := m.versions()
+	for v := range applied {
+		if _, ok := m.migrations[v]; !ok {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	result := make([]Status, 0, len(versions))
+	for _, v := range versions {
+		status := Status{Version: v, Description: m.migrations[v].Description}
+		if r, ok := applied[v]; ok {
+			status.Applied = true
+			status.AppliedAt = r.AppliedAt
+			status.Description = r.Description
+		}
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+func (m *Migrator) up(migration Migration) error {
+	logger.Infof("[Migrate] Applying %d: %s", migration.Version, migration.Description)
+
+	err := migration.Up(m.database)
+	if err != nil {
+		return fmt.Errorf("migration %d failed: %w", migration.Version, err)
+	}
+
+	_, err = m.database.InsertOne(m.opt.collection, record{
+		Version:     migration.Version,
+		Description: migration.Description,
+		AppliedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: version %d applied: %v", ErrNotRecorded, migration.Version, err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) down(version int64) error {
+	migration, ok := m.migrations[version]
+	if !ok {
+		return fmt.Errorf("migration %d is applied but not registered", version)
+	}
+	if migration.Down == nil {
+		return fmt.Errorf("migration %d cannot be rolled back", version)
+	}
+
+	logger.Infof("[Migrate] Rolling back %d: %s", migration.Version, migration.Description)
+
+	err := migration.Down(m.database)
+	if err != nil {
+		return fmt.Errorf("rollback of migration %d failed: %w", version, err)
+	}
+
+	_, err = m.database.DeleteOne(m.opt.collection, db.Filter{"_id": version})
+	if err != nil {
+		return fmt.Errorf("%w: version %d rolled back: %v", ErrNotRecorded, version, err)
+	}
+
+	return nil
+}
+
+// locked runs fn with the applied migrations while holding the lock
+func (m *Migrator) locked(fn func(applied map[int64]record) error) error {
+	err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer m.unlock()
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	return fn(applied)
+}
+
+func (m *Migrator) lock() error {
+	now := time.Now().UTC()
+	expiresAt := now.Add(m.opt.lockTimeout)
+	lockCollection := m.opt.collection + "_lock"
+
+	_, err := m.database.InsertOne(lockCollection, lock{ID: lockID, Owner: m.owner, ExpiresAt: expiresAt})
+	if err == nil {
+		return nil
+	}
+
+	// Take over the lock if the holder did not release it in time
+	var current lock
+	err = m.database.ApplyDB(lockCollection,
+		db.Filter{"_id": lockID, "expires_at": db.Filter{"$lt": now}},
+		db.Filter{"$set": db.Filter{"owner": m.owner, "expires_at": expiresAt}},
+		&current)
+	if err == nil {
+		logger.Warn("[Migrate] Took over expired lock")
+		return nil
+	}
+
+	if findErr := m.database.FindOne(lockCollection, db.Filter{"_id": lockID}, nil, &current); findErr == nil {
+		return ErrLocked
+	}
+
+	return err
+}
+
+func (m *Migrator) unlock() {
+	_, err := m.database.DeleteOne(m.opt.collection+"_lock", db.Filter{"_id": lockID, "owner": m.owner})
+	if err != nil {
+		logger.Error("[Migrate] Failed to release lock: ", err)
+	}
+}
+
+func (m *Migrator) applied() (map[int64]record, error) {
+	var records []record
+	err := m.database.FindMany(m.opt.collection, db.Filter{}, &db.FindOptions{Sort: db.Sort{"_id"}}, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// versions returns the registered versions in ascending order
+func (m *Migrator) versions() []int64 {
+	versions := make([]int64, 0, len(m.migrations))
+	for v := range m.migrations {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	return versions
+}
+
+// appliedVersions returns the applied versions in descending order
+func appliedVersions(applied map[int64]record) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	return versions
+}
+
+func isApplied(applied map[int64]record, version int64) bool {
+	_, ok := applied[version]
+	return ok
+}
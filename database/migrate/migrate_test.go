@@ -0,0 +1,268 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+// fakeMongo keeps the records and the lock of the migrator in memory
+type fakeMongo struct {
+	db.Mongo
+	records    map[int64]record
+	lock       *lock
+	failRecord bool
+}
+
+func newFakeMongo() *fakeMongo {
+	return &fakeMongo{records: make(map[int64]record)}
+}
+
+func (f *fakeMongo) InsertOne(_ string, payload interface{}) (*db.InsertOneResult, error) {
+	switch v := payload.(type) {
+	case lock:
+		if f.lock != nil {
+			return nil, errors.New("duplicate key")
+		}
+		f.lock = &v
+	case record:
+		if f.failRecord {
+			return nil, errors.New("connection lost")
+		}
+		f.records[v.Version] = v
+	}
+
+	return &db.InsertOneResult{}, nil
+}
+
+func (f *fakeMongo) ApplyDB(_ string, _ db.Filter, payload interface{}, _ interface{}) error {
+	if f.lock == nil || !f.lock.ExpiresAt.Before(time.Now()) {
+		return errors.New("not found")
+	}
+
+	set := payload.(db.Filter)["$set"].(db.Filter)
+	f.lock.Owner = set["owner"].(string)
+	f.lock.ExpiresAt = set["expires_at"].(time.Time)
+
+	return nil
+}
+
+func (f *fakeMongo) FindOne(string, db.Filter, *db.FindOptions, interface{}) error {
+	if f.lock == nil {
+		return errors.New("not found")
+	}
+
+	return nil
+}
+
+func (f *fakeMongo) FindMany(_ string, _ db.Filter, _ *db.FindOptions, result interface{}) error {
+	records := make([]record, 0, len(f.records))
+	for _, r := range f.records {
+		records = append(records, r)
+	}
+	*result.(*[]record) = records
+
+	return nil
+}
+
+func (f *fakeMongo) DeleteOne(_ string, selector db.Filter) (*db.DeleteResult, error) {
+	if selector["_id"] == lockID {
+		if f.lock != nil && f.lock.Owner == selector["owner"] {
+			f.lock = nil
+		}
+		return &db.DeleteResult{}, nil
+	}
+
+	delete(f.records, selector["_id"].(int64))
+	return &db.DeleteResult{}, nil
+}
+
+// register adds the versions to the migrator, the calls of their up and down
+// are appended to calls
+func register(m *Migrator, calls *[]string, versions ...int64) {
+	for _, v := range versions {
+		v := v
+		m.Register(v, "migration", func(db.Mongo) error {
+			*calls = append(*calls, fmt.Sprint("up ", v))
+			return nil
+		}, func(db.Mongo) error {
+			*calls = append(*calls, fmt.Sprint("down ", v))
+			return nil
+		})
+	}
+}
+
+func appliedOf(database *fakeMongo) []int64 {
+	applied := appliedVersions(database.records)
+	if len(applied) == 0 {
+		return nil
+	}
+
+	return applied
+}
+
+func TestUpInOrder(t *testing.T) {
+	database := newFakeMongo()
+	m := New(database)
+	var calls []string
+	register(m, &calls, 3, 1, 2)
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	if want := []string{"up 1", "up 2", "up 3"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	if want := []int64{3, 2, 1}; !reflect.DeepEqual(appliedOf(database), want) {
+		t.Errorf("applied = %v, want %v", appliedOf(database), want)
+	}
+	if database.lock != nil {
+		t.Errorf("lock was not released")
+	}
+}
+
+func TestTo(t *testing.T) {
+	database := newFakeMongo()
+	m := New(database)
+	var calls []string
+	register(m, &calls, 1, 2, 3)
+
+	if err := m.To(2); err != nil {
+		t.Fatalf("To(2): %v", err)
+	}
+	if want := []int64{2, 1}; !reflect.DeepEqual(appliedOf(database), want) {
+		t.Errorf("applied after To(2) = %v, want %v", appliedOf(database), want)
+	}
+
+	if err := m.To(3); err != nil {
+		t.Fatalf("To(3): %v", err)
+	}
+
+	calls = nil
+	if err := m.To(1); err != nil {
+		t.Fatalf("To(1): %v", err)
+	}
+	if want := []string{"down 3", "down 2"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls of To(1) = %q, want %q", calls, want)
+	}
+	if want := []int64{1}; !reflect.DeepEqual(appliedOf(database), want) {
+		t.Errorf("applied after To(1) = %v, want %v", appliedOf(database), want)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	database := newFakeMongo()
+	m := New(database)
+	var calls []string
+	register(m, &calls, 1, 2, 3)
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	calls = nil
+	if err := m.Rollback(2); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if want := []string{"down 3", "down 2"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	m.Register(4, "irreversible", func(db.Mongo) error { return nil }, nil)
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := m.Rollback(1); err == nil {
+		t.Errorf("Rollback of a migration without down succeeded")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	database := newFakeMongo()
+	m := New(database)
+	var calls []string
+	register(m, &calls, 1, 2)
+	if err := m.To(1); err != nil {
+		t.Fatalf("To: %v", err)
+	}
+	database.records[5] = record{Version: 5, Description: "removed", AppliedAt: time.Unix(5, 0)}
+
+	status, err := m.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+
+	var got []string
+	for _, s := range status {
+		got = append(got, fmt.Sprintf("%d %s %v", s.Version, s.Description, s.Applied))
+	}
+	want := []string{"1 migration true", "2 migration false", "5 removed true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Status = %q, want %q", got, want)
+	}
+}
+
+func TestLock(t *testing.T) {
+	database := newFakeMongo()
+	database.lock = &lock{ID: lockID, Owner: "other", ExpiresAt: time.Now().Add(time.Minute)}
+	m := New(database)
+	var calls []string
+	register(m, &calls, 1)
+
+	if err := m.Up(); err != ErrLocked {
+		t.Fatalf("Up with a held lock = %v, want ErrLocked", err)
+	}
+
+	database.lock.ExpiresAt = time.Now().Add(-time.Second)
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up with an expired lock: %v", err)
+	}
+	if len(calls) != 1 || database.lock != nil {
+		t.Errorf("calls = %q, lock = %v, want the migration applied and the lock released", calls, database.lock)
+	}
+}
+
+func TestNotRecorded(t *testing.T) {
+	database := newFakeMongo()
+	database.failRecord = true
+	m := New(database)
+	var calls []string
+	register(m, &calls, 7)
+
+	err := m.Up()
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("Up = %v, want ErrNotRecorded", err)
+	}
+	if want := "migration ran but was not recorded: version 7 applied: connection lost"; err.Error() != want {
+		t.Errorf("Up = %q, want %q", err, want)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int64
+		up      Func
+	}{
+		{"nil up", 2, nil},
+		{"duplicate", 1, func(db.Mongo) error { return nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(newFakeMongo())
+			m.Register(1, "first", func(db.Mongo) error { return nil }, nil)
+
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register did not panic")
+				}
+			}()
+			m.Register(tt.version, tt.name, tt.up, nil)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	collection  string
+	lockTimeout time.Duration
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithCollection sets the collection which records the applied migrations, the
+// lock is kept in the collection with suffix _lock. Default is migrations.
+func WithCollection(collection string) Option {
+	return optionFn(func(opt *option) {
+		opt.collection = collection
+	})
+}
+
+// WithLockTimeout sets how long the lock is held before another replica can
+// take it over, it should be longer than the longest migration
+func WithLockTimeout(timeout time.Duration) Option {
+	return optionFn(func(opt *option) {
+		opt.lockTimeout = timeout
+	})
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{
+		collection:  "migrations",
+		lockTimeout: 10 * time.Minute,
+	}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+
+	return &opt
+}
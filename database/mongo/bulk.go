@@ -0,0 +1,301 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+// bulkRunner runs a chunk of operations starting at offset, it returns the
+// result and the errors of the chunk with the indexes of all operations
+type bulkRunner func(chunk []db.WriteModel, offset int) (*db.BulkWriteResult, error)
+
+// bulkWrite runs the operations by chunks of database.BulkChunkSize with run. In
+// ordered mode the chunks after a failed chunk are not run.
+func bulkWrite(ops []db.WriteModel, ordered bool, run bulkRunner) (*db.BulkWriteResult, error) {
+	result := &db.BulkWriteResult{UpsertedIDs: make(map[int]interface{})}
+	var writeErrors []db.WriteError
+
+	for offset := 0; offset < len(ops); offset += db.BulkChunkSize {
+		end := offset + db.BulkChunkSize
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		chunk, err := run(ops[offset:end], offset)
+		if chunk != nil {
+			mergeBulkResult(result, chunk)
+		}
+		if err != nil {
+			var bulkErr *db.BulkWriteError
+			if !errors.As(err, &bulkErr) {
+				return result, err
+			}
+			writeErrors = append(writeErrors, bulkErr.WriteErrors...)
+			if ordered {
+				break
+			}
+		}
+	}
+
+	if len(writeErrors) > 0 {
+		return result, &db.BulkWriteError{WriteErrors: writeErrors}
+	}
+
+	return result, nil
+}
+
+func mergeBulkResult(result, chunk *db.BulkWriteResult) {
+	result.InsertedCount += chunk.InsertedCount
+	result.MatchedCount += chunk.MatchedCount
+	result.ModifiedCount += chunk.ModifiedCount
+	result.DeletedCount += chunk.DeletedCount
+	result.UpsertedCount += chunk.UpsertedCount
+	for i, id := range chunk.UpsertedIDs {
+		result.UpsertedIDs[i] = id
+	}
+}
+
+// filterOf returns an empty filter for nil, which matches all documents
+func filterOf(filter db.Filter) db.Filter {
+	if filter == nil {
+		return db.Filter{}
+	}
+
+	return filter
+}
+
+// writeModels converts the operations to the write models of mongo-driver
+func writeModels(ops []db.WriteModel) ([]mongo.WriteModel, error) {
+	models := make([]mongo.WriteModel, len(ops))
+	for i, op := range ops {
+		switch op := op.(type) {
+		case db.InsertOneModel:
+			models[i] = mongo.NewInsertOneModel().SetDocument(op.Document)
+		case db.UpdateOneModel:
+			models[i] = mongo.NewUpdateOneModel().SetFilter(filterOf(op.Filter)).SetUpdate(op.Update).SetUpsert(op.Upsert)
+		case db.UpdateManyModel:
+			models[i] = mongo.NewUpdateManyModel().SetFilter(filterOf(op.Filter)).SetUpdate(op.Update).SetUpsert(op.Upsert)
+		case db.ReplaceOneModel:
+			models[i] = mongo.NewReplaceOneModel().SetFilter(filterOf(op.Filter)).SetReplacement(op.Replacement).SetUpsert(op.Upsert)
+		case db.DeleteOneModel:
+			models[i] = mongo.NewDeleteOneModel().SetFilter(filterOf(op.Filter))
+		case db.DeleteManyModel:
+			models[i] = mongo.NewDeleteManyModel().SetFilter(filterOf(op.Filter))
+		default:
+			return nil, fmt.Errorf("unknown write model %T", op)
+		}
+	}
+
+	return models, nil
+}
+
+func driverBulkRunner(ctx context.Context, collection *mongo.Collection, ordered bool) bulkRunner {
+	return func(chunk []db.WriteModel, offset int) (*db.BulkWriteResult, error) {
+		models, err := writeModels(chunk)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(ordered))
+		return driverBulkResult(result, err, offset)
+	}
+}
+
+// driverBulkResult converts the result and the write errors of a chunk of
+// mongo-driver starting at offset
+func driverBulkResult(result *mongo.BulkWriteResult, err error, offset int) (*db.BulkWriteResult, error) {
+	var chunk *db.BulkWriteResult
+	if result != nil {
+		chunk = &db.BulkWriteResult{
+			InsertedCount: result.InsertedCount,
+			MatchedCount:  result.MatchedCount,
+			ModifiedCount: result.ModifiedCount,
+			DeletedCount:  result.DeletedCount,
+			UpsertedCount: result.UpsertedCount,
+			UpsertedIDs:   make(map[int]interface{}, len(result.UpsertedIDs)),
+		}
+		for i, id := range result.UpsertedIDs {
+			chunk.UpsertedIDs[int(i)+offset] = normalizeID(id)
+		}
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+		writeErrors := make([]db.WriteError, len(bulkErr.WriteErrors))
+		for i, e := range bulkErr.WriteErrors {
+			writeErrors[i] = db.WriteError{Index: e.Index + offset, Code: e.Code, Message: e.Message}
+		}
+		return chunk, &db.BulkWriteError{WriteErrors: writeErrors}
+	}
+
+	return chunk, err
+}
+
+type mgoOpKind int
+
+const (
+	mgoInsert mgoOpKind = iota
+	mgoUpdate
+	mgoUpsert
+	mgoDelete
+)
+
+func mgoKindOf(op db.WriteModel) mgoOpKind {
+	switch op := op.(type) {
+	case db.InsertOneModel:
+		return mgoInsert
+	case db.UpdateOneModel:
+		if op.Upsert {
+			return mgoUpsert
+		}
+	case db.ReplaceOneModel:
+		if op.Upsert {
+			return mgoUpsert
+		}
+	case db.DeleteOneModel, db.DeleteManyModel:
+		return mgoDelete
+	}
+
+	return mgoUpdate
+}
+
+func mgoBulkRunner(collection *mgo.Collection, ordered bool) bulkRunner {
+	return func(chunk []db.WriteModel, offset int) (*db.BulkWriteResult, error) {
+		return mgoBulkWrite(collection, chunk, ordered, offset)
+	}
+}
+
+// mgoBulkWrite runs a chunk with mgo, consecutive operations of the same kind
+// are sent in one bulk so the counts of mgo can be attributed to them. mgo does
+// not return the counts of a failed bulk, the inserted count is derived from the
+// failed operations but the matched, modified and deleted counts of a group of
+// updates or deletes with a failed operation are lost.
+func mgoBulkWrite(collection *mgo.Collection, ops []db.WriteModel, ordered bool, offset int) (*db.BulkWriteResult, error) {
+	result := &db.BulkWriteResult{UpsertedIDs: make(map[int]interface{})}
+	var writeErrors []db.WriteError
+
+	for start := 0; start < len(ops); {
+		kind := mgoKindOf(ops[start])
+		end := start + 1
+		for end < len(ops) && mgoKindOf(ops[end]) == kind {
+			end++
+		}
+
+		bulk := collection.Bulk()
+		if !ordered {
+			bulk.Unordered()
+		}
+		for _, op := range ops[start:end] {
+			if err := addMgoOp(bulk, op); err != nil {
+				return result, err
+			}
+		}
+
+		res, err := bulk.Run()
+		if err != nil {
+			var bulkErr *mgo.BulkError
+			if !errors.As(err, &bulkErr) {
+				return result, err
+			}
+			for _, c := range bulkErr.Cases() {
+				writeErrors = append(writeErrors, mgoWriteError(c, offset+start))
+			}
+			if kind == mgoInsert {
+				result.InsertedCount += mgoInserted(bulkErr.Cases(), end-start, ordered)
+			}
+			if ordered {
+				break
+			}
+		} else {
+			switch kind {
+			case mgoInsert:
+				result.InsertedCount += int64(end - start)
+			case mgoDelete:
+				result.DeletedCount += int64(res.Matched)
+			default:
+				result.MatchedCount += int64(res.Matched)
+				result.ModifiedCount += int64(res.Modified)
+			}
+		}
+
+		start = end
+	}
+
+	if len(writeErrors) > 0 {
+		return result, &db.BulkWriteError{WriteErrors: writeErrors}
+	}
+
+	return result, nil
+}
+
+// mgoInserted returns the number of documents inserted by a failed bulk of n
+// inserts, the cases are sorted by index. It returns 0 when a case has no index.
+func mgoInserted(cases []mgo.BulkErrorCase, n int, ordered bool) int64 {
+	failed := 0
+	for _, c := range cases {
+		if c.Index < 0 {
+			return 0
+		}
+		if ordered {
+			return int64(c.Index)
+		}
+		failed++
+	}
+
+	return int64(n - failed)
+}
+
+func addMgoOp(bulk *mgo.Bulk, op db.WriteModel) error {
+	switch op := op.(type) {
+	case db.InsertOneModel:
+		bulk.Insert(op.Document)
+	case db.UpdateOneModel:
+		if op.Upsert {
+			bulk.Upsert(filterOf(op.Filter), op.Update)
+		} else {
+			bulk.Update(filterOf(op.Filter), op.Update)
+		}
+	case db.UpdateManyModel:
+		if op.Upsert {
+			return errors.New("upsert of many documents is not supported by mgo bulk")
+		}
+		bulk.UpdateAll(filterOf(op.Filter), op.Update)
+	case db.ReplaceOneModel:
+		if op.Upsert {
+			bulk.Upsert(filterOf(op.Filter), op.Replacement)
+		} else {
+			bulk.Update(filterOf(op.Filter), op.Replacement)
+		}
+	case db.DeleteOneModel:
+		bulk.Remove(filterOf(op.Filter))
+	case db.DeleteManyModel:
+		bulk.RemoveAll(filterOf(op.Filter))
+	default:
+		return fmt.Errorf("unknown write model %T", op)
+	}
+
+	return nil
+}
+
+func mgoWriteError(c mgo.BulkErrorCase, offset int) db.WriteError {
+	e := db.WriteError{Index: c.Index, Message: c.Err.Error()}
+	if e.Index >= 0 {
+		e.Index += offset
+	}
+
+	switch err := c.Err.(type) {
+	case *mgo.LastError:
+		e.Code = err.Code
+	case *mgo.QueryError:
+		e.Code = err.Code
+	}
+
+	return e
+}
@@ -0,0 +1,31 @@
+package mongo
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/mgo.v2"
+)
+
+func TestMgoInserted(t *testing.T) {
+	dup := errors.New("duplicate key")
+	tests := []struct {
+		name    string
+		cases   []mgo.BulkErrorCase
+		n       int
+		ordered bool
+		want    int64
+	}{
+		{"ordered stops at the first failure", []mgo.BulkErrorCase{{Index: 2, Err: dup}}, 5, true, 2},
+		{"unordered runs the others", []mgo.BulkErrorCase{{Index: 1, Err: dup}, {Index: 3, Err: dup}}, 5, false, 3},
+		{"unknown index", []mgo.BulkErrorCase{{Index: -1, Err: dup}}, 5, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mgoInserted(tt.cases, tt.n, tt.ordered); got != tt.want {
+				t.Errorf("mgoInserted = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
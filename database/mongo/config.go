@@ -0,0 +1,221 @@
+package mongo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/utils/logger"
+)
+
+const defaultConnectionTimeout = 10 * time.Second
+
+func connectionTimeout(config db.Config) time.Duration {
+	if config.ConnectionTimeout > 0 {
+		return time.Duration(config.ConnectionTimeout) * time.Second
+	}
+
+	return defaultConnectionTimeout
+}
+
+// connectionURI builds the connection string of mongo-driver from the config,
+// the credentials are escaped and the replica set name is set if Env is
+// "replica", as for mgo. The TLS files are not part of the URI, they are loaded
+// by tlsConfig.
+func connectionURI(config db.Config) string {
+	u := url.URL{Scheme: "mongodb", Host: config.Hosts, Path: "/"}
+	if config.SRV {
+		u.Scheme = "mongodb+srv"
+	}
+
+	query := url.Values{}
+	if config.AuthDatabase != "" {
+		query.Set("authSource", config.AuthDatabase)
+	}
+	if config.Env == "replica" && config.Replica != "" {
+		query.Set("replicaSet", config.Replica)
+	}
+	if config.ConnectionTimeout > 0 {
+		query.Set("connectTimeoutMS", strconv.FormatInt(connectionTimeout(config).Milliseconds(), 10))
+	}
+	if config.ReadPreference != "" {
+		query.Set("readPreference", config.ReadPreference)
+	}
+	if config.WriteConcern != "" {
+		query.Set("w", config.WriteConcern)
+	}
+	if config.MinPoolSize > 0 {
+		query.Set("minPoolSize", strconv.Itoa(config.MinPoolSize))
+	}
+	if config.MaxPoolSize > 0 {
+		query.Set("maxPoolSize", strconv.Itoa(config.MaxPoolSize))
+	}
+	if config.AppName != "" {
+		query.Set("appName", config.AppName)
+	}
+	if len(config.Compressors) > 0 {
+		query.Set("compressors", strings.Join(config.Compressors, ","))
+	}
+	if config.DisableRetryWrites {
+		query.Set("retryWrites", "false")
+	}
+	u.RawQuery = query.Encode()
+
+	uri := u.String()
+	if config.AuthUserName == "" {
+		return uri
+	}
+
+	// The driver query unescapes the user info, which url.UserPassword does not
+	// fully escape, e.g. + would be read as a space
+	userInfo := url.QueryEscape(config.AuthUserName)
+	if config.AuthPassword != "" {
+		userInfo += ":" + url.QueryEscape(config.AuthPassword)
+	}
+
+	return strings.Replace(uri, "://", "://"+userInfo+"@", 1)
+}
+
+// tlsConfig returns the TLS config of the connection, nil if TLS is disabled.
+// The certificate key file contains the client certificate and its key.
+func tlsConfig(config db.Config) (*tls.Config, error) {
+	if !config.TLS && !config.SRV {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{InsecureSkipVerify: config.TLSInsecure}
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in the CA file %s", config.TLSCAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if config.TLSCertificateKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCertificateKeyFile, config.TLSCertificateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
+
+// mgoDialInfo returns the dial info of mgo for the config, the replica set name
+// is set if Env is "replica". mgo does not support the min pool size, app name
+// and compressors, they are ignored.
+func mgoDialInfo(config db.Config) (*mgo.DialInfo, error) {
+	info := &mgo.DialInfo{
+		Addrs:     strings.Split(config.Hosts, ","),
+		Timeout:   connectionTimeout(config),
+		Database:  config.AuthDatabase,
+		Username:  config.AuthUserName,
+		Password:  config.AuthPassword,
+		PoolLimit: config.MaxPoolSize,
+	}
+	if config.Env == "replica" {
+		info.ReplicaSetName = config.Replica
+	}
+
+	if config.SRV {
+		addrs, params, err := lookupSRV(config.Hosts)
+		if err != nil {
+			return nil, err
+		}
+
+		info.Addrs = addrs
+		if info.ReplicaSetName == "" {
+			info.ReplicaSetName = params.Get("replicaSet")
+		}
+		if info.Database == "" {
+			info.Database = params.Get("authSource")
+		}
+	}
+
+	tlsCfg, err := tlsConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		dialer := &net.Dialer{Timeout: info.Timeout}
+		info.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
+			return tls.DialWithDialer(dialer, "tcp", addr.String(), tlsCfg)
+		}
+	}
+
+	if config.MinPoolSize > 0 || config.AppName != "" || len(config.Compressors) > 0 {
+		logger.Warn("[mgoDialInfo] Min pool size, app name and compressors are not supported by mgo, they are ignored")
+	}
+
+	return info, nil
+}
+
+// lookupSRV returns the hosts of a mongodb+srv:// host from its SRV records and
+// the options of its TXT record, which is optional
+func lookupSRV(host string) ([]string, url.Values, error) {
+	_, records, err := net.LookupSRV("mongodb", "tcp", host)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addrs := make([]string, len(records))
+	for i, record := range records {
+		addrs[i] = net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+	}
+
+	params := url.Values{}
+	if txts, err := net.LookupTXT(host); err == nil && len(txts) > 0 {
+		params, err = url.ParseQuery(txts[0])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return addrs, params, nil
+}
+
+// mgoMode returns the mode of mgo of a read preference
+func mgoMode(readPreference string) (mgo.Mode, error) {
+	switch readPreference {
+	case "", "primary":
+		return mgo.Primary, nil
+	case "primaryPreferred":
+		return mgo.PrimaryPreferred, nil
+	case "secondary":
+		return mgo.Secondary, nil
+	case "secondaryPreferred":
+		return mgo.SecondaryPreferred, nil
+	case "nearest":
+		return mgo.Nearest, nil
+	default:
+		return 0, fmt.Errorf("unknown read preference %s", readPreference)
+	}
+}
+
+// mgoSafe returns the safety mode of mgo of a write concern, w is "majority", a
+// number of nodes or a tag set name
+func mgoSafe(w string) *mgo.Safe {
+	if w == "" {
+		return &mgo.Safe{}
+	}
+	if n, err := strconv.Atoi(w); err == nil {
+		return &mgo.Safe{W: n}
+	}
+
+	return &mgo.Safe{WMode: w}
+}
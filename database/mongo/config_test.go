@@ -0,0 +1,72 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+func TestConnectionURICredentials(t *testing.T) {
+	tests := []struct {
+		user, password string
+	}{
+		{"user", "pass"},
+		{"us+er", "p@ss:w/rd?&=$%+ "},
+		{"user", ""},
+	}
+
+	for _, tt := range tests {
+		config := db.Config{Hosts: "localhost:27017", AuthDatabase: "admin", AuthUserName: tt.user, AuthPassword: tt.password}
+		cs, err := connstring.Parse(connectionURI(config))
+		if err != nil {
+			t.Fatalf("Parse %s: %v", connectionURI(config), err)
+		}
+
+		if cs.Username != tt.user || cs.Password != tt.password || cs.PasswordSet != (tt.password != "") {
+			t.Errorf("credentials of %s = %q %q, want %q %q", connectionURI(config), cs.Username, cs.Password, tt.user, tt.password)
+		}
+		if cs.AuthSource != "admin" {
+			t.Errorf("auth source of %s = %q, want admin", connectionURI(config), cs.AuthSource)
+		}
+	}
+}
+
+func TestConnectionURIOptions(t *testing.T) {
+	config := db.Config{
+		Hosts:              "a:27017,b:27017",
+		Env:                "replica",
+		Replica:            "rs0",
+		ConnectionTimeout:  3,
+		ReadPreference:     "secondaryPreferred",
+		WriteConcern:       "majority",
+		MaxPoolSize:        20,
+		AppName:            "gosdk",
+		DisableRetryWrites: true,
+	}
+
+	cs, err := connstring.Parse(connectionURI(config))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if len(cs.Hosts) != 2 || cs.ReplicaSet != "rs0" || cs.ConnectTimeout != 3*time.Second ||
+		cs.ReadPreference != "secondaryPreferred" || cs.WString != "majority" || cs.MaxPoolSize != 20 ||
+		cs.AppName != "gosdk" || !cs.RetryWritesSet || cs.RetryWrites || cs.Username != "" {
+		t.Errorf("connection string %s parsed as %+v", connectionURI(config), cs)
+	}
+}
+
+func TestConnectionURIReplicaSet(t *testing.T) {
+	config := db.Config{Hosts: "a:27017", Replica: "rs0"}
+	cs, err := connstring.Parse(connectionURI(config))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if cs.ReplicaSet != "" {
+		t.Errorf("replica set of %s = %q, want none without the replica env", connectionURI(config), cs.ReplicaSet)
+	}
+}
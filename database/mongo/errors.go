@@ -0,0 +1,123 @@
+package mongo
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"gopkg.in/mgo.v2"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+// maxTimeExpiredCode is the code of the server error when the max time of an
+// operation is exceeded
+const maxTimeExpiredCode = 50
+
+// namespaceNotFoundCode is the code of the server error when the collection does
+// not exist
+const namespaceNotFoundCode = 26
+
+// translateErr maps the errors of the drivers to the types of the errors
+// package, so the callers do not depend on the backend: no document is
+// errors.NotFound, a duplicate key errors.DuplicateError, also in the write
+// errors of a bulk write, and a timeout errors.Timeout. Other errors keep their
+// type, which is errors.Unknown for the driver errors. The collection and the
+// operation are added as the field and message of the error context, the
+// original error is reachable with errors.Is and errors.As of the standard
+// library, e.g. the *database.BulkWriteError of BulkWrite.
+func translateErr(err error, collection string, operation string) error {
+	if err == nil {
+		return nil
+	}
+
+	var translated error
+	switch {
+	case isNotFound(err):
+		translated = errors.NotFound.Wrapf(err, "%s %s", operation, collection)
+	case isDuplicate(err):
+		translated = errors.DuplicateError.Wrapf(err, "%s %s", operation, collection)
+	case isTimeout(err):
+		translated = errors.Timeout.Wrapf(err, "%s %s", operation, collection)
+	default:
+		translated = errors.Wrapf(err, "%s %s", operation, collection)
+	}
+
+	return errors.AddErrorContext(translated, collection, operation)
+}
+
+func isNotFound(err error) bool {
+	return stderrors.Is(err, mongo.ErrNoDocuments) || stderrors.Is(err, mgo.ErrNotFound)
+}
+
+func isDuplicate(err error) bool {
+	if mgo.IsDup(err) {
+		return true
+	}
+
+	var writeErr mongo.WriteException
+	if stderrors.As(err, &writeErr) {
+		for _, e := range writeErr.WriteErrors {
+			if isDuplicateCode(e.Code) {
+				return true
+			}
+		}
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if stderrors.As(err, &bulkErr) {
+		for _, e := range bulkErr.WriteErrors {
+			if isDuplicateCode(e.Code) {
+				return true
+			}
+		}
+	}
+
+	var sdkBulkErr *db.BulkWriteError
+	if stderrors.As(err, &sdkBulkErr) {
+		for _, e := range sdkBulkErr.WriteErrors {
+			if isDuplicateCode(e.Code) {
+				return true
+			}
+		}
+	}
+
+	var commandErr mongo.CommandError
+	if stderrors.As(err, &commandErr) {
+		return isDuplicateCode(int(commandErr.Code))
+	}
+
+	return false
+}
+
+func isDuplicateCode(code int) bool {
+	return code == 11000 || code == 11001 || code == 12582
+}
+
+func isTimeout(err error) bool {
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var commandErr mongo.CommandError
+	if stderrors.As(err, &commandErr) && commandErr.IsMaxTimeMSExpiredError() {
+		return true
+	}
+
+	var queryErr *mgo.QueryError
+	if stderrors.As(err, &queryErr) && queryErr.Code == maxTimeExpiredCode {
+		return true
+	}
+
+	var netErr net.Error
+	return stderrors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isNamespaceNotFound(err error) bool {
+	var queryErr *mgo.QueryError
+	return stderrors.As(err, &queryErr) &&
+		(queryErr.Code == namespaceNotFoundCode || strings.Contains(queryErr.Message, "ns does not exist") || queryErr.Message == "ns not found")
+}
@@ -0,0 +1,61 @@
+package mongo
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"gopkg.in/mgo.v2"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+func TestTranslateErr(t *testing.T) {
+	commandErr := mongo.CommandError{Code: 8000, Name: "AtlasError", Labels: []string{"TransientTransactionError"}}
+	tests := []struct {
+		name string
+		err  error
+		want errors.ErrorType
+	}{
+		{"not found", mongo.ErrNoDocuments, errors.NotFound},
+		{"mgo not found", mgo.ErrNotFound, errors.NotFound},
+		{"duplicate", mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}, errors.DuplicateError},
+		{"mgo duplicate", &mgo.LastError{Code: 11000}, errors.DuplicateError},
+		{"bulk duplicate", &db.BulkWriteError{WriteErrors: []db.WriteError{{Index: 1, Code: 11000}}}, errors.DuplicateError},
+		{"bulk error", &db.BulkWriteError{WriteErrors: []db.WriteError{{Index: 1, Code: 2}}}, errors.Unknown},
+		{"max time", mongo.CommandError{Code: 50, Name: "MaxTimeMSExpired"}, errors.Timeout},
+		{"mgo max time", &mgo.QueryError{Code: 50}, errors.Timeout},
+		{"driver error", commandErr, errors.Unknown},
+		{"sdk error", db.ErrInvalidCursor, errors.BadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := translateErr(tt.err, "brand", "FindOne")
+			if got := errors.GetType(err); got != tt.want {
+				t.Errorf("type = %v, want %v", got, tt.want)
+			}
+
+			want := map[string]string{"field": "brand", "message": "FindOne"}
+			if got := errors.GetErrorContext(err); got["field"] != want["field"] || got["message"] != want["message"] {
+				t.Errorf("context = %v, want %v", got, want)
+			}
+		})
+	}
+
+	var labeled mongo.CommandError
+	if err := translateErr(commandErr, "brand", "FindOne"); !stderrors.As(err, &labeled) || !labeled.HasErrorLabel("TransientTransactionError") {
+		t.Errorf("translated %v does not unwrap to the labeled command error", err)
+	}
+	var bulkErr *db.BulkWriteError
+	if err := translateErr(tests[4].err, "brand", "BulkWrite"); !stderrors.As(err, &bulkErr) {
+		t.Errorf("translated %v does not unwrap to the bulk write error", err)
+	}
+	if err := translateErr(db.ErrInvalidCursor, "brand", "FindManyCursor"); !stderrors.Is(err, db.ErrInvalidCursor) {
+		t.Errorf("translated %v is not ErrInvalidCursor", err)
+	}
+	if translateErr(nil, "brand", "FindOne") != nil {
+		t.Error("translated nil is not nil")
+	}
+}
@@ -0,0 +1,136 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+// errCollationNotSupported is returned by the find methods of mgo with a
+// collation, mgo cannot send the collation of a query
+var errCollationNotSupported = errors.BadRequest.New("collation of queries is not supported by mgo")
+
+func collationOf(c *db.Collation) *options.Collation {
+	if c == nil {
+		return nil
+	}
+
+	collation := options.Collation(*c)
+	return &collation
+}
+
+// findOptions converts the find options to the options of mongo-driver
+func findOptions(opts *db.FindOptions) *options.FindOptions {
+	findOpts := options.Find()
+	if opts == nil {
+		return findOpts
+	}
+
+	if len(opts.Sort) > 0 {
+		findOpts.SetSort(sortOf(opts.Sort))
+	}
+	if opts.Projection != nil {
+		findOpts.SetProjection(opts.Projection)
+	}
+	if opts.Skip > 0 {
+		findOpts.SetSkip(int64(opts.Skip))
+	}
+	if opts.Limit > 0 {
+		findOpts.SetLimit(int64(opts.Limit))
+	}
+	if len(opts.Hint) > 0 {
+		findOpts.SetHint(sortOf(opts.Hint))
+	}
+	if opts.Collation != nil {
+		findOpts.SetCollation(collationOf(opts.Collation))
+	}
+	if opts.MaxTime > 0 {
+		findOpts.SetMaxTime(opts.MaxTime)
+	}
+
+	return findOpts
+}
+
+// findOneOptions converts the find options to the options of FindOne of
+// mongo-driver, the limit is ignored
+func findOneOptions(opts *db.FindOptions) *options.FindOneOptions {
+	findOpts := options.FindOne()
+	if opts == nil {
+		return findOpts
+	}
+
+	if len(opts.Sort) > 0 {
+		findOpts.SetSort(sortOf(opts.Sort))
+	}
+	if opts.Projection != nil {
+		findOpts.SetProjection(opts.Projection)
+	}
+	if opts.Skip > 0 {
+		findOpts.SetSkip(int64(opts.Skip))
+	}
+	if len(opts.Hint) > 0 {
+		findOpts.SetHint(sortOf(opts.Hint))
+	}
+	if opts.Collation != nil {
+		findOpts.SetCollation(collationOf(opts.Collation))
+	}
+	if opts.MaxTime > 0 {
+		findOpts.SetMaxTime(opts.MaxTime)
+	}
+
+	return findOpts
+}
+
+// countOptions converts the find options which apply to a count
+func countOptions(opts *db.FindOptions) *options.CountOptions {
+	countOpts := options.Count()
+	if opts == nil {
+		return countOpts
+	}
+
+	if len(opts.Hint) > 0 {
+		countOpts.SetHint(sortOf(opts.Hint))
+	}
+	if opts.Collation != nil {
+		countOpts.SetCollation(collationOf(opts.Collation))
+	}
+	if opts.MaxTime > 0 {
+		countOpts.SetMaxTime(opts.MaxTime)
+	}
+
+	return countOpts
+}
+
+// mgoQuery applies the find options to the query of mgo, skip and limit are
+// applied if withBounds is true
+func mgoQuery(query *mgo.Query, opts *db.FindOptions, withBounds bool) (*mgo.Query, error) {
+	if opts == nil {
+		return query, nil
+	}
+	if opts.Collation != nil {
+		return nil, errCollationNotSupported
+	}
+
+	if len(opts.Sort) > 0 {
+		query = query.Sort(opts.Sort...)
+	}
+	if opts.Projection != nil {
+		query = query.Select(opts.Projection)
+	}
+	if withBounds && opts.Skip > 0 {
+		query = query.Skip(opts.Skip)
+	}
+	if withBounds && opts.Limit > 0 {
+		query = query.Limit(opts.Limit)
+	}
+	if len(opts.Hint) > 0 {
+		query = query.Hint(opts.Hint...)
+	}
+	if opts.MaxTime > 0 {
+		query = query.SetMaxTime(opts.MaxTime)
+	}
+
+	return query, nil
+}
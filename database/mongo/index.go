@@ -0,0 +1,218 @@
+package mongo
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+// mgoIndex translates the index config to the index of mgo, both use the same
+// syntax of keys.
+func mgoIndex(index db.IndexConfig) mgo.Index {
+	return mgo.Index{
+		Key:              index.Key,
+		Unique:           index.Unique,
+		DropDups:         index.DropDups,
+		Background:       index.Background,
+		Sparse:           index.Sparse,
+		ExpireAfter:      index.ExpireAfter,
+		Name:             index.Name,
+		Min:              index.Min,
+		Max:              index.Max,
+		Minf:             index.Minf,
+		Maxf:             index.Maxf,
+		BucketSize:       index.BucketSize,
+		Bits:             index.Bits,
+		DefaultLanguage:  index.DefaultLanguage,
+		LanguageOverride: index.LanguageOverride,
+		Weights:          index.Weights,
+	}
+}
+
+// indexConfigsOf translates the indexes of mgo to the index configs
+func indexConfigsOf(indexes []mgo.Index) []db.IndexConfig {
+	var results []db.IndexConfig
+	for _, index := range indexes {
+		results = append(results, indexConfigOf(index))
+	}
+
+	return results
+}
+
+func indexConfigOf(index mgo.Index) db.IndexConfig {
+	return db.IndexConfig{
+		Key:              index.Key,
+		Unique:           index.Unique,
+		DropDups:         index.DropDups,
+		Background:       index.Background,
+		Sparse:           index.Sparse,
+		ExpireAfter:      index.ExpireAfter,
+		Name:             index.Name,
+		Min:              index.Min,
+		Max:              index.Max,
+		Minf:             index.Minf,
+		Maxf:             index.Maxf,
+		BucketSize:       index.BucketSize,
+		Bits:             index.Bits,
+		DefaultLanguage:  index.DefaultLanguage,
+		LanguageOverride: index.LanguageOverride,
+		Weights:          index.Weights,
+	}
+}
+
+// indexModel translates the index config to the index model of mongo-driver, the
+// keys follow the syntax "[$<kind>:][-]<field name>", e.g. "-created_at",
+// "$text:name" or "$2dsphere:location".
+func indexModel(index db.IndexConfig) mongo.IndexModel {
+	var keys bson.D
+	for _, key := range index.Key {
+		var order interface{} = 1
+		if strings.HasPrefix(key, "$") {
+			if c := strings.Index(key, ":"); c > 1 {
+				order = key[1:c]
+				key = key[c+1:]
+			}
+		} else if strings.HasPrefix(key, "@") {
+			order = "2d"
+			key = key[1:]
+		} else if strings.HasPrefix(key, "-") {
+			order = -1
+			key = key[1:]
+		} else {
+			key = strings.TrimPrefix(key, "+")
+		}
+		keys = append(keys, bson.E{Key: key, Value: order})
+	}
+
+	opts := options.Index().
+		SetUnique(index.Unique).
+		SetSparse(index.Sparse).
+		SetBackground(index.Background)
+	if index.Name != "" {
+		opts.SetName(index.Name)
+	}
+	if index.ExpireAfter > 0 {
+		opts.SetExpireAfterSeconds(int32(index.ExpireAfter.Seconds()))
+	}
+	if index.Minf != 0 || index.Maxf != 0 {
+		opts.SetMin(index.Minf).SetMax(index.Maxf)
+	} else if index.Min != 0 || index.Max != 0 {
+		opts.SetMin(float64(index.Min)).SetMax(float64(index.Max))
+	}
+	if index.BucketSize > 0 {
+		opts.SetBucketSize(int32(index.BucketSize))
+	}
+	if index.Bits > 0 {
+		opts.SetBits(int32(index.Bits))
+	}
+	if index.DefaultLanguage != "" {
+		opts.SetDefaultLanguage(index.DefaultLanguage)
+	}
+	if index.LanguageOverride != "" {
+		opts.SetLanguageOverride(index.LanguageOverride)
+	}
+	if len(index.Weights) > 0 {
+		opts.SetWeights(index.Weights)
+	}
+
+	return mongo.IndexModel{Keys: keys, Options: opts}
+}
+
+// indexSpec is the index document returned by listIndexes
+type indexSpec struct {
+	Name               string  `bson:"name"`
+	Key                bson.D  `bson:"key"`
+	Unique             bool    `bson:"unique"`
+	Sparse             bool    `bson:"sparse"`
+	Background         bool    `bson:"background"`
+	ExpireAfterSeconds float64 `bson:"expireAfterSeconds"`
+	Min                float64 `bson:"min"`
+	Max                float64 `bson:"max"`
+	BucketSize         float64 `bson:"bucketSize"`
+	Bits               int     `bson:"bits"`
+	DefaultLanguage    string  `bson:"default_language"`
+	LanguageOverride   string  `bson:"language_override"`
+	Weights            bson.D  `bson:"weights"`
+}
+
+type indexSpecs []indexSpec
+
+func (specs indexSpecs) indexConfigs() []db.IndexConfig {
+	var results []db.IndexConfig
+	for _, spec := range specs {
+		results = append(results, spec.indexConfig())
+	}
+
+	return results
+}
+
+// indexConfig translates the index document to the index config, the text keys
+// are rebuilt from the weights like mgo does.
+func (spec indexSpec) indexConfig() db.IndexConfig {
+	index := db.IndexConfig{
+		Name:             spec.Name,
+		Unique:           spec.Unique,
+		Sparse:           spec.Sparse,
+		Background:       spec.Background,
+		ExpireAfter:      time.Duration(spec.ExpireAfterSeconds) * time.Second,
+		Minf:             spec.Min,
+		Maxf:             spec.Max,
+		BucketSize:       spec.BucketSize,
+		Bits:             spec.Bits,
+		DefaultLanguage:  spec.DefaultLanguage,
+		LanguageOverride: spec.LanguageOverride,
+	}
+
+	for _, e := range spec.Key {
+		if e.Key == "_fts" {
+			for _, w := range spec.Weights {
+				index.Key = append(index.Key, "$text:"+w.Key)
+			}
+			continue
+		}
+		if e.Key == "_ftsx" {
+			continue
+		}
+
+		switch order := e.Value.(type) {
+		case string:
+			index.Key = append(index.Key, "$"+order+":"+e.Key)
+		case int32:
+			index.Key = append(index.Key, keyOf(e.Key, float64(order)))
+		case int64:
+			index.Key = append(index.Key, keyOf(e.Key, float64(order)))
+		case float64:
+			index.Key = append(index.Key, keyOf(e.Key, order))
+		}
+	}
+
+	if len(spec.Weights) > 0 {
+		index.Weights = make(map[string]int)
+		for _, w := range spec.Weights {
+			switch weight := w.Value.(type) {
+			case int32:
+				index.Weights[w.Key] = int(weight)
+			case int64:
+				index.Weights[w.Key] = int(weight)
+			case float64:
+				index.Weights[w.Key] = int(weight)
+			}
+		}
+	}
+
+	return index
+}
+
+func keyOf(field string, order float64) string {
+	if order < 0 {
+		return "-" + field
+	}
+
+	return field
+}
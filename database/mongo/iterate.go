@@ -0,0 +1,15 @@
+package mongo
+
+import (
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+// iterateErr returns the error to return from Iterate for the error of its
+// function, stopping the iteration is not an error
+func iterateErr(err error) error {
+	if err == db.ErrStopIteration {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,206 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/errors"
+	"github.com/quangdangfit/gosdk/utils/paging"
+)
+
+// keyset is the sort of a keyset paginated query, _id is appended as the last
+// field so the order of documents is total
+type keyset struct {
+	fields []string
+	orders []int
+}
+
+// token is the content of a continuation token, the values are the sort keys
+// of the document the page starts after
+type token struct {
+	Backward bool          `bson:"b"`
+	Fields   []string      `bson:"f"`
+	Orders   []int         `bson:"o"`
+	Values   []interface{} `bson:"v"`
+}
+
+func keysetOf(sort db.Sort) keyset {
+	var k keyset
+	hasID := false
+	for _, field := range sort {
+		order := 1
+		if strings.HasPrefix(field, "-") {
+			order = -1
+		}
+		field = strings.TrimLeft(field, "+-")
+		hasID = hasID || field == "_id"
+
+		k.fields = append(k.fields, field)
+		k.orders = append(k.orders, order)
+	}
+
+	if !hasID {
+		order := 1
+		if len(k.orders) > 0 {
+			order = k.orders[len(k.orders)-1]
+		}
+		k.fields = append(k.fields, "_id")
+		k.orders = append(k.orders, order)
+	}
+
+	return k
+}
+
+// sort returns the sort of the query, which is reversed to read backward
+func (k keyset) sort(backward bool) db.Sort {
+	sort := make(db.Sort, len(k.fields))
+	for i, field := range k.fields {
+		if (k.orders[i] < 0) != backward {
+			sort[i] = "-" + field
+		} else {
+			sort[i] = field
+		}
+	}
+
+	return sort
+}
+
+// filter restricts the query to the documents after the values in the order of
+// the keyset, or before them to read backward:
+//
+//	{$or: [{a: {$gt: va}}, {a: va, _id: {$gt: vid}}]}
+func (k keyset) filter(query db.Filter, t *token) db.Filter {
+	if t == nil {
+		return query
+	}
+
+	clauses := make([]db.Filter, len(k.fields))
+	for i, field := range k.fields {
+		clause := db.Filter{}
+		for j := 0; j < i; j++ {
+			clause[k.fields[j]] = t.Values[j]
+		}
+
+		operator := "$gt"
+		if (k.orders[i] < 0) != t.Backward {
+			operator = "$lt"
+		}
+		clause[field] = db.Filter{operator: t.Values[i]}
+		clauses[i] = clause
+	}
+
+	after := db.Filter{"$or": clauses}
+	if len(query) == 0 {
+		return after
+	}
+
+	return db.Filter{"$and": []db.Filter{query, after}}
+}
+
+// valid reports whether the token was made for the keyset
+func (k keyset) valid(t *token) bool {
+	return reflect.DeepEqual(t.Fields, k.fields) && reflect.DeepEqual(t.Orders, k.orders) &&
+		len(t.Values) == len(k.fields)
+}
+
+// missingKey is the error of a document without a value of a keyset field, the
+// documents after it cannot be matched as null is not greater nor less than the
+// other values
+func missingKey(field string) error {
+	return errors.BadRequest.Newf("sort field %s is missing or null, keyset pagination needs sort fields set in every document", field)
+}
+
+// cursorPage trims the documents read with limit+1 to the page, puts them in
+// the order of the keyset and returns the tokens of the page. values returns the
+// sort keys of a document.
+func cursorPage(k keyset, t *token, docs []interface{}, limit int,
+	values func(doc interface{}) ([]interface{}, error),
+	encode func(t token) (string, error)) ([]interface{}, *db.Cursor, error) {
+
+	backward := t != nil && t.Backward
+	more := len(docs) > limit
+	if more {
+		docs = docs[:limit]
+	}
+	if backward {
+		for i, j := 0, len(docs)-1; i < j; i, j = i+1, j-1 {
+			docs[i], docs[j] = docs[j], docs[i]
+		}
+	}
+
+	cursor := &db.Cursor{}
+	if len(docs) == 0 {
+		return docs, cursor, nil
+	}
+
+	// There is a page after if more documents were read forward or if the page
+	// was read backward from a token, and the other way round
+	hasNext := more || backward
+	hasPrev := (backward && more) || (!backward && t != nil)
+
+	if hasNext {
+		v, err := values(docs[len(docs)-1])
+		if err != nil {
+			return nil, nil, err
+		}
+		cursor.Next, err = encode(token{Fields: k.fields, Orders: k.orders, Values: v})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if hasPrev {
+		v, err := values(docs[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		cursor.Prev, err = encode(token{Backward: true, Fields: k.fields, Orders: k.orders, Values: v})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return docs, cursor, nil
+}
+
+func encodeToken(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeToken(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, db.ErrInvalidCursor
+	}
+
+	return b, nil
+}
+
+// decodeInto sets the slice pointed by results to the documents, each document
+// is unmarshalled into a new element
+func decodeInto(docs []interface{}, results interface{}, unmarshal func(doc interface{}, v interface{}) error) error {
+	slice := reflect.ValueOf(results)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		panic("result argument must be a slice address")
+	}
+	slice = slice.Elem()
+
+	elems := reflect.MakeSlice(slice.Type(), len(docs), len(docs))
+	for i, doc := range docs {
+		if err := unmarshal(doc, elems.Index(i).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	slice.Set(elems)
+
+	return nil
+}
+
+func cursorLimit(limit int) int {
+	if limit <= 0 {
+		return paging.DefaultLimit
+	}
+
+	return limit
+}
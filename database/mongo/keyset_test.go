@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mgobson "gopkg.in/mgo.v2/bson"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+func TestParseTokenOrders(t *testing.T) {
+	ascending, descending := keysetOf(db.Sort{"rank"}), keysetOf(db.Sort{"-rank"})
+	next := token{Fields: ascending.fields, Orders: ascending.orders, Values: []interface{}{1, "id"}}
+
+	cursor, err := formatToken(next)
+	if err != nil {
+		t.Fatalf("formatToken: %v", err)
+	}
+	if _, err := parseToken(ascending, cursor); err != nil {
+		t.Errorf("parseToken of the same sort: %v", err)
+	}
+	if _, err := parseToken(descending, cursor); err != db.ErrInvalidCursor {
+		t.Errorf("parseToken of the reversed sort = %v, want ErrInvalidCursor", err)
+	}
+
+	cursor, err = formatMgoToken(next)
+	if err != nil {
+		t.Fatalf("formatMgoToken: %v", err)
+	}
+	if _, err := parseMgoToken(ascending, cursor); err != nil {
+		t.Errorf("parseMgoToken of the same sort: %v", err)
+	}
+	if _, err := parseMgoToken(descending, cursor); err != db.ErrInvalidCursor {
+		t.Errorf("parseMgoToken of the reversed sort = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestKeyValuesMissing(t *testing.T) {
+	k := keysetOf(db.Sort{"meta.rank"})
+	docs := map[string]bson.M{
+		"set":     {"_id": 1, "meta": bson.M{"rank": 2}},
+		"missing": {"_id": 1, "meta": bson.M{}},
+		"null":    {"_id": 1, "meta": bson.M{"rank": nil}},
+	}
+
+	for name, doc := range docs {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		_, err = keyValues(k)(bson.Raw(raw))
+		if (name == "set") != (err == nil) {
+			t.Errorf("keyValues of %s field = %v", name, err)
+		}
+		if err != nil && errors.GetType(err) != errors.BadRequest {
+			t.Errorf("keyValues of %s field = %v, want a BadRequest error", name, err)
+		}
+
+		_, err = mgoKeyValues(k)(mgobson.Raw{Kind: 3, Data: raw})
+		if (name == "set") != (err == nil) {
+			t.Errorf("mgoKeyValues of %s field = %v", name, err)
+		}
+	}
+}
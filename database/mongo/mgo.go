@@ -1,11 +1,13 @@
 package mongo
 
 import (
+	"context"
+	"errors"
 	"log"
 	"strings"
-	"time"
 
 	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 
 	db "github.com/quangdangfit/gosdk/database"
 	"github.com/quangdangfit/gosdk/utils/logger"
@@ -16,82 +18,105 @@ type gomgo struct {
 	conn *mgo.Database
 }
 
-func NewMongo(config db.Config) db.Mongo {
-	var s *mgo.Session
+var _ db.Mongo = (*gomgo)(nil)
 
-	if config.Env == "replica" {
-		s = replicaSetConnection(config)
-	} else {
-		s = nativeConnection(config)
+// NewMongo dials the mongodb of the config with mgo, it exits if the connection
+// fails.
+//
+// Deprecated: use DialMongo, which returns the error.
+func NewMongo(config db.Config) db.Mongo {
+	database, err := DialMongo(config)
+	if err != nil {
+		logger.Fatal(err)
 	}
-	db := mgo.Database{Session: s, Name: config.Database}
-	return &gomgo{conn: &db}
+
+	return database
 }
 
+// NewWithConnString dials the mongodb of the connection string with mgo, it
+// exits if the connection fails.
+//
+// Deprecated: use DialWithConnString, which returns the error.
 func NewWithConnString(uri string) db.Mongo {
-	dialInfo, err := mgo.ParseURL(uri)
-	if err != nil {
-		logger.Fatal("Connection string is invalid")
-	}
-
-	dialInfo.Timeout = time.Duration(10)
-	session, err := mgo.DialWithInfo(dialInfo)
+	database, err := DialWithConnString(uri)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	db := mgo.Database{Session: session, Name: dialInfo.Database}
-	return &gomgo{conn: &db}
+	return database
 }
 
-func nativeConnection(config db.Config) *mgo.Session {
-	logger.Info("[nativeConnection] Connecting mongodb")
+// DialMongo dials the mongodb of the config with mgo, the replica set of the
+// config is used if Env is "replica". The error of the connection is returned.
+func DialMongo(config db.Config) (db.Mongo, error) {
+	logger.Info("[DialMongo] Connecting mongodb")
 
-	var timeout = 10
-	if config.ConnectionTimeout > 0 {
-		timeout = config.ConnectionTimeout
+	mode, err := mgoMode(config.ReadPreference)
+	if err != nil {
+		return nil, err
 	}
-	mongoDBDialInfo := &mgo.DialInfo{
-		Addrs:    []string{config.Hosts},
-		Timeout:  time.Duration(timeout) * time.Second,
-		Database: config.AuthDatabase,
-		Username: config.AuthUserName,
-		Password: config.AuthPassword,
+	dialInfo, err := mgoDialInfo(config)
+	if err != nil {
+		return nil, err
 	}
+
 	// Create a session which maintains a pool of socket connections
-	session, err := mgo.DialWithInfo(mongoDBDialInfo)
+	session, err := mgo.DialWithInfo(dialInfo)
 	if err != nil {
-		logger.Fatal("[nativeConnection] Failed to connect gomgo: ", err)
+		logger.Error("[DialMongo] Failed to connect mongodb: ", err)
+		return nil, err
 	}
 
-	session.SetSafe(&mgo.Safe{})
-	logger.Info("[nativeConnection] Mongodb connected")
-	return session
-}
+	session.SetMode(mode, true)
+	session.SetSafe(mgoSafe(config.WriteConcern))
+	logger.Info("[DialMongo] Mongodb connected")
 
-func replicaSetConnection(config db.Config) *mgo.Session {
-	logger.Info("[replicaSetConnection] Connecting mongodb")
+	db := mgo.Database{Session: session, Name: config.Database}
+	return &gomgo{conn: &db}, nil
+}
 
-	var timeout = 10
-	if config.ConnectionTimeout > 0 {
-		timeout = config.ConnectionTimeout
+// DialWithConnString dials the mongodb of the connection string with mgo, the
+// error of the connection is returned.
+func DialWithConnString(uri string) (db.Mongo, error) {
+	dialInfo, err := mgo.ParseURL(uri)
+	if err != nil {
+		return nil, errors.New("connection string is invalid")
 	}
-	session, err := mgo.DialWithInfo(&mgo.DialInfo{
-		Addrs:          strings.Split(config.Hosts, ","),
-		Username:       config.AuthUserName,
-		Password:       config.AuthPassword,
-		Database:       config.AuthDatabase,
-		ReplicaSetName: config.Replica,
-		Timeout:        time.Duration(timeout) * time.Second,
-	})
+
+	dialInfo.Timeout = defaultConnectionTimeout
+	session, err := mgo.DialWithInfo(dialInfo)
 	if err != nil {
-		log.Println("[replicaSetConnection] ERROR: ", err)
+		return nil, err
 	}
 
-	session.SetSafe(&mgo.Safe{})
-	logger.Info("[replicaSetConnection] Connected to replica set success!")
+	db := mgo.Database{Session: session, Name: dialInfo.Database}
+	return &gomgo{conn: &db}, nil
+}
+
+// Ping checks the connection by sending a ping to the server, mgo does not take
+// a context so Ping returns when ctx is done without waiting for the ping.
+func (db *gomgo) Ping(ctx context.Context) error {
+	sessionClone := db.conn.Session.Copy()
+
+	done := make(chan error, 1)
+	go func() {
+		defer sessionClone.Close()
+		done <- sessionClone.Ping()
+	}()
 
-	return session
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close closes the session and its connections, the database cannot be used
+// after.
+func (db *gomgo) Close(ctx context.Context) error {
+	db.conn.Session.Close()
+	return nil
 }
 
 // GetSession will return a copy session from session of database, remember close session at the end.
@@ -102,12 +127,12 @@ func (db *gomgo) GetSession() *mgo.Session {
 // EnsureIndex ensures an index with the given collection name and key exists, creating it with
 // the provided parameters if necessary. EnsureIndex does not modify a previously
 // existent index with a matching key. The old index must be dropped first instead.
-func (db *gomgo) EnsureIndex(collectionName string, index mgo.Index) bool {
+func (db *gomgo) EnsureIndex(collectionName string, index db.IndexConfig) bool {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	err := collection.EnsureIndex(index)
+	err := collection.EnsureIndex(mgoIndex(index))
 	if err != nil {
 		log.Println("[EnsureIndex] Ensure index name fail: ", index.Name, " - ERROR: ", err)
 		return false
@@ -131,74 +156,200 @@ func (db *gomgo) DropIndex(collectionName string, name string) bool {
 	return true
 }
 
+// DropCollection drops the collection with its indexes, a missing collection is
+// not an error.
+func (db *gomgo) DropCollection(collectionName string) error {
+	sessionClone := db.conn.Session.Copy()
+	defer sessionClone.Close()
+	collection := sessionClone.DB(db.conn.Name).C(collectionName)
+
+	err := collection.DropCollection()
+	if isNamespaceNotFound(err) {
+		return nil
+	}
+
+	return translateErr(err, collectionName, "DropCollection")
+}
+
+// ListIndexes returns the indexes of the provided collection name, a missing
+// collection has no index.
+func (db *gomgo) ListIndexes(collectionName string) ([]db.IndexConfig, error) {
+	sessionClone := db.conn.Session.Copy()
+	defer sessionClone.Close()
+	collection := sessionClone.DB(db.conn.Name).C(collectionName)
+
+	indexes, err := collection.Indexes()
+	if isNamespaceNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, translateErr(err, collectionName, "ListIndexes")
+	}
+
+	return indexConfigsOf(indexes), nil
+}
+
 // FindOne executes the query and unmarshals the first obtained document into the
-// result argument, using the provided collection name, query and sort.
+// result argument, using the provided collection name, query and find options.
 // The query may be a map or a struct value capable of being marshalled with bson.
-// The sort is field name need to sort, a field name may be prefixed by - (minus) for
-// it to be sorted in reverse order.
-func (db *gomgo) FindOne(collectionName string, query map[string]interface{}, sort string, TResult interface{}) (err error) {
+// The sort of opts is field name need to sort, a field name may be prefixed by -
+// (minus) for it to be sorted in reverse order. mgo does not support a collation.
+func (db *gomgo) FindOne(collectionName string, query db.Filter, opts *db.FindOptions, TResult interface{}) (err error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	if sort != "" {
-		cursor := collection.Find(query).Sort(sort)
-		err = cursor.One(TResult)
-	} else {
-		err = collection.Find(query).One(TResult)
+	cursor, err := mgoQuery(collection.Find(query), opts, true)
+	if err != nil {
+		return translateErr(err, collectionName, "FindOne")
 	}
 
+	err = cursor.One(TResult)
 	if err != nil {
-		return err
+		return translateErr(err, collectionName, "FindOne")
 	}
 
 	return nil
 }
 
 // FindMany executes the query and unmarshals the all obtained document into the
-// result argument, using the provided collection name, query, sort, and result interface.
+// result argument, using the provided collection name, query, find options, and result interface.
 // The query may be a map or a struct value capable of being marshalled with bson.
-// The sort is field name need to sort, a field name may be prefixed by - (minus) for
-// it to be sorted in reverse order.
-func (db *gomgo) FindMany(collectionName string, query map[string]interface{}, sort string, TResult interface{}) (err error) {
+// The sort of opts is field name need to sort, a field name may be prefixed by -
+// (minus) for it to be sorted in reverse order. mgo does not support a collation.
+func (db *gomgo) FindMany(collectionName string, query db.Filter, opts *db.FindOptions, TResult interface{}) (err error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	if sort != "" {
-		cursor := collection.Find(query).Sort(sort)
-		err = cursor.All(TResult)
-	} else {
-		err = collection.Find(query).All(TResult)
+	cursor, err := mgoQuery(collection.Find(query), opts, true)
+	if err != nil {
+		return translateErr(err, collectionName, "FindMany")
 	}
 
+	err = cursor.All(TResult)
 	if err != nil {
-		return err
+		return translateErr(err, collectionName, "FindMany")
 	}
 
 	return nil
 }
 
-// FindMany executes FindMany function but skip by page parameter and limit by limit parameter
-func (db *gomgo) FindManyPaging(collectionName string, query map[string]interface{}, sort string, page int, limit int, TResult interface{}) (*paging.Paging, error) {
+// FindManyPaging executes FindMany function but skip by page parameter and limit by limit parameter.
+// The skip and limit of opts are ignored. A page of no documents is not an
+// error, the total is not counted with paging.WithoutTotal.
+func (db *gomgo) FindManyPaging(collectionName string, query db.Filter, opts *db.FindOptions, page int, limit int, TResult interface{}, pagingOpts ...paging.Option) (*paging.Paging, error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	cursor := collection.Find(query).Sort(sort)
-	total, _ := cursor.Count()
-	if total == 0 {
-		return nil, mgo.ErrNotFound
+	cursor, err := mgoQuery(collection.Find(query), opts, false)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "FindManyPaging")
 	}
-	pagingObj := paging.New(page, limit, total)
-	err := cursor.Skip(pagingObj.Skip).Limit(pagingObj.Limit).All(TResult)
-	if err == mgo.ErrNotFound {
-		return nil, err
+
+	total := paging.UnknownTotal
+	if paging.CountTotal(pagingOpts...) {
+		total, err = cursor.Count()
+		if err != nil {
+			return nil, translateErr(err, collectionName, "FindManyPaging")
+		}
+	}
+
+	pagingObj := paging.New(page, limit, total, pagingOpts...)
+	err = cursor.Skip(pagingObj.Skip).Limit(readLimit(pagingObj)).All(TResult)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "FindManyPaging")
 	}
+	trimPage(pagingObj, TResult)
 
 	return pagingObj, nil
 }
 
+// FindManyCursor executes the query and unmarshals a page of limit documents
+// into the result argument, the page starts after the continuation token of the
+// previous call or at the first document if cursor is empty. The documents are
+// ordered by the sort and _id, the tokens of the pages after and before are
+// returned. Unlike FindManyPaging the documents are not counted nor skipped. The
+// sort fields must be set in every document, a page ending with a document
+// missing one is a BadRequest error.
+func (db *gomgo) FindManyCursor(collectionName string, query db.Filter, sort db.Sort, cursor string, limit int, TResult interface{}) (*db.Cursor, error) {
+	sessionClone := db.conn.Session.Copy()
+	defer sessionClone.Close()
+	collection := sessionClone.DB(db.conn.Name).C(collectionName)
+
+	k := keysetOf(sort)
+	t, err := parseMgoToken(k, cursor)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "FindManyCursor")
+	}
+	limit = cursorLimit(limit)
+
+	var raws []bson.Raw
+	err = collection.Find(k.filter(query, t)).Sort(k.sort(t != nil && t.Backward)...).Limit(limit + 1).All(&raws)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "FindManyCursor")
+	}
+
+	docs := make([]interface{}, len(raws))
+	for i, raw := range raws {
+		docs[i] = raw
+	}
+
+	docs, c, err := cursorPage(k, t, docs, limit, mgoKeyValues(k), formatMgoToken)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "FindManyCursor")
+	}
+
+	err = decodeInto(docs, TResult, func(doc interface{}, v interface{}) error {
+		raw := doc.(bson.Raw)
+		return raw.Unmarshal(v)
+	})
+	if err != nil {
+		return nil, translateErr(err, collectionName, "FindManyCursor")
+	}
+
+	return c, nil
+}
+
+// Iterate executes the query and calls fn for each obtained document, decode
+// unmarshals the document into v. The documents are read by batches, so they
+// are not all loaded in memory, and the cursor is closed at the end. Iterate
+// stops at the first error of fn, which is returned unless it is
+// database.ErrStopIteration, or when ctx is done.
+func (db *gomgo) Iterate(ctx context.Context, collectionName string, query db.Filter, opts *db.IterOptions, fn func(decode func(v interface{}) error) error) (err error) {
+	sessionClone := db.conn.Session.Copy()
+	defer sessionClone.Close()
+	collection := sessionClone.DB(db.conn.Name).C(collectionName)
+
+	cursor := collection.Find(query)
+	if opts != nil {
+		cursor, err = mgoQuery(cursor, &opts.FindOptions, true)
+		if err != nil {
+			return translateErr(err, collectionName, "Iterate")
+		}
+		if opts.BatchSize > 0 {
+			cursor = cursor.Batch(opts.BatchSize)
+		}
+	}
+
+	iter := cursor.Iter()
+	defer func() {
+		if closeErr := iter.Close(); err == nil {
+			err = translateErr(closeErr, collectionName, "Iterate")
+		}
+	}()
+
+	var raw bson.Raw
+	for ctx.Err() == nil && iter.Next(&raw) {
+		if err = fn(raw.Unmarshal); err != nil {
+			return iterateErr(err)
+		}
+	}
+
+	return ctx.Err()
+}
+
 // PipeAll prepares a pipeline to aggregate. The pipeline document
 // must be a slice built in terms of the aggregation framework language.
 func (db *gomgo) PipeAll(collectionName string, pipeline interface{}, TResult interface{}) (err error) {
@@ -208,122 +359,157 @@ func (db *gomgo) PipeAll(collectionName string, pipeline interface{}, TResult in
 
 	err = collection.Pipe(pipeline).All(TResult)
 	if err != nil {
-		return err
+		return translateErr(err, collectionName, "PipeAll")
 	}
 
 	return nil
 }
 
 // Insert inserts one document in the respective collection, the returned error will
-// be an error.
-func (db *gomgo) InsertOne(collectionName string, payload interface{}) (err error) {
+// be an error. An ObjectID is generated as _id if the document has none.
+func (db *gomgo) InsertOne(collectionName string, payload interface{}) (*db.InsertOneResult, error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	err = collection.Insert(payload)
+	doc, id, err := withID(payload)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "InsertOne")
 	}
 
-	return nil
+	err = collection.Insert(doc)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "InsertOne")
+	}
+
+	return insertedOne(id), nil
 }
 
 // InsertMany queues up the provided documents for insertion and run insert.
-func (db *gomgo) InsertMany(collectionName string, payload []interface{}) (err error) {
+func (db *gomgo) InsertMany(collectionName string, payload []interface{}) (*db.InsertManyResult, error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	bulk := collection.Bulk()
-	bulk.Insert(payload...)
-	_, err = bulk.Run()
+	docs := make([]interface{}, len(payload))
+	ids := make([]interface{}, len(payload))
+	for i, p := range payload {
+		doc, id, err := withID(p)
+		if err != nil {
+			return nil, translateErr(err, collectionName, "InsertMany")
+		}
+		docs[i], ids[i] = doc, id
+	}
 
+	bulk := collection.Bulk()
+	bulk.Insert(docs...)
+	_, err := bulk.Run()
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "InsertMany")
 	}
-	return nil
+
+	return insertedMany(ids), nil
 }
 
 // Upsert finds a single document matching the provided selector document
 // and modifies it according to the update document.
-func (db *gomgo) Upsert(collectionName string, selector interface{}, payload interface{}) (err error) {
+func (db *gomgo) Upsert(collectionName string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	_, err = collection.Upsert(selector, payload)
+	info, err := collection.Upsert(selector, payload)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "Upsert")
 	}
 
-	return nil
+	return changeResult(info), nil
 }
 
 // UpdateOne finds a single document matching the provided selector document
-// and modifies it according to the update document.
-func (db *gomgo) UpdateOne(collectionName string, selector interface{}, payload interface{}) (err error) {
+// and modifies it according to the update document. It is not an error if no
+// document matches.
+func (db *gomgo) UpdateOne(collectionName string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	err = collection.Update(selector, payload)
+	bulk := collection.Bulk()
+	bulk.Update(selector, payload)
+	result, err := bulk.Run()
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "UpdateOne")
 	}
 
-	return nil
+	return bulkUpdateResult(result), nil
 }
 
 // UpdateMany finds all documents matching the provided selector document
 // and modifies them according to the update document.
-func (db *gomgo) UpdateMany(collectionName string, selector interface{}, payload interface{}) (err error) {
+func (db *gomgo) UpdateMany(collectionName string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	_, err = collection.UpdateAll(selector, payload)
+	info, err := collection.UpdateAll(selector, payload)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "UpdateMany")
 	}
 
-	return nil
+	return changeResult(info), nil
 }
 
 // DeleteOne finds a single document matching the provided selector document
-// and removes it from the database.
-func (db *gomgo) DeleteOne(collectionName string, selector interface{}) (err error) {
+// and removes it from the database. It is not an error if no document matches.
+func (db *gomgo) DeleteOne(collectionName string, selector db.Filter) (*db.DeleteResult, error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	err = collection.Remove(selector)
+	bulk := collection.Bulk()
+	bulk.Remove(selector)
+	result, err := bulk.Run()
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "DeleteOne")
 	}
 
-	return nil
+	return deleteResult(int64(result.Matched)), nil
 }
 
 // DeleteMany finds all documents matching the provided selector document
 // and removes them from the database.
-func (db *gomgo) DeleteMany(collectionName string, selector interface{}) (err error) {
+func (db *gomgo) DeleteMany(collectionName string, selector db.Filter) (*db.DeleteResult, error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
 
-	_, err = collection.RemoveAll(selector)
+	info, err := collection.RemoveAll(selector)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "DeleteMany")
 	}
 
-	return nil
+	return deleteResult(int64(info.Removed)), nil
+}
+
+// BulkWrite runs the mixed insert, update, replace and delete operations, by
+// chunks of database.BulkChunkSize. In ordered mode the operations run in order
+// and stop at the first error, otherwise all operations are tried. The errors of
+// operations are returned as *database.BulkWriteError with the partial result.
+// mgo does not report upserts of bulk operations, so upserted documents are
+// counted as matched, and upsert of many documents is not supported.
+func (db *gomgo) BulkWrite(collectionName string, ops []db.WriteModel, ordered bool) (*db.BulkWriteResult, error) {
+	sessionClone := db.conn.Session.Copy()
+	defer sessionClone.Close()
+	collection := sessionClone.DB(db.conn.Name).C(collectionName)
+
+	result, err := bulkWrite(ops, ordered, mgoBulkRunner(collection, ordered))
+	return result, translateErr(err, collectionName, "BulkWrite")
 }
 
 // Apply runs the findAndModify Database command, which allows updating, upserting
 // or removing a document matching a query and atomically returning either the old
 // version (the default) or the new version of the document.
-func (db *gomgo) ApplyDB(collectionName string, selector interface{}, payload interface{}, TResult interface{}) (err error) {
+func (db *gomgo) ApplyDB(collectionName string, selector db.Filter, payload interface{}, TResult interface{}) (err error) {
 	sessionClone := db.conn.Session.Copy()
 	defer sessionClone.Close()
 	collection := sessionClone.DB(db.conn.Name).C(collectionName)
@@ -331,8 +517,67 @@ func (db *gomgo) ApplyDB(collectionName string, selector interface{}, payload in
 	change := mgo.Change{Update: payload, ReturnNew: true}
 	_, err = collection.Find(selector).Apply(change, TResult)
 	if err != nil {
-		return err
+		return translateErr(err, collectionName, "ApplyDB")
 	}
 
 	return nil
 }
+
+// parseMgoToken decodes a continuation token of the keyset, the token is nil if
+// cursor is empty
+func parseMgoToken(k keyset, cursor string) (*token, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	b, err := decodeToken(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var t token
+	if err := bson.Unmarshal(b, &t); err != nil || !k.valid(&t) {
+		return nil, db.ErrInvalidCursor
+	}
+
+	return &t, nil
+}
+
+func formatMgoToken(t token) (string, error) {
+	b, err := bson.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	return encodeToken(b), nil
+}
+
+// mgoKeyValues returns the values of the keyset fields of a raw document, a
+// missing or null field is an error
+func mgoKeyValues(k keyset) func(doc interface{}) ([]interface{}, error) {
+	return func(doc interface{}) ([]interface{}, error) {
+		var m bson.M
+		raw := doc.(bson.Raw)
+		if err := raw.Unmarshal(&m); err != nil {
+			return nil, err
+		}
+
+		values := make([]interface{}, len(k.fields))
+		for i, field := range k.fields {
+			var value interface{} = m
+			for _, key := range strings.Split(field, ".") {
+				if sub, ok := value.(bson.M); ok {
+					value = sub[key]
+				} else {
+					value = nil
+				}
+			}
+			if value == nil {
+				return nil, missingKey(field)
+			}
+			values[i] = value
+		}
+
+		return values, nil
+	}
+}
@@ -0,0 +1,33 @@
+package mongo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/quangdangfit/gosdk/database/mongo"
+	"github.com/quangdangfit/gosdk/database/mongotest"
+)
+
+// testURI returns the connection string of the test server, the test is skipped
+// if MONGO_TEST_URI is not set, e.g. mongodb://localhost:27017/gosdk_test
+func testURI(t *testing.T) string {
+	t.Helper()
+
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	return uri
+}
+
+func TestMgo(t *testing.T) {
+	database, err := mongo.DialWithConnString(testURI(t))
+	if err != nil {
+		t.Fatalf("DialWithConnString: %v", err)
+	}
+	defer database.Close(context.Background())
+
+	mongotest.Run(t, database, mongotest.WithoutCollation(), mongotest.WithoutFailedBulkCounts())
+}
@@ -2,10 +2,11 @@ package mongo
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -16,236 +17,708 @@ import (
 )
 
 type mongodb struct {
-	conn *mongo.Database
+	conn    *mongo.Database
+	timeout time.Duration
+	opt     *option
+
+	// session and ctx are set in the copy of a transaction
+	session mongo.Session
+	ctx     context.Context
 }
 
-func NewWithConfig(config db.Config) *mongodb {
-	logger.Info("Connecting mongodb, database: ", config.Database)
+var _ db.MongoContext = (*mongodb)(nil)
 
-	connectionURI := "mongodb://"
-	if config.AuthUserName != "" && config.AuthPassword != "" {
-		connectionURI += fmt.Sprintf("%s:%s@", config.AuthUserName, config.AuthPassword)
-	}
-	connectionURI += config.Hosts
-	if config.AuthDatabase != "" {
-		connectionURI += fmt.Sprintf("/?authSource=%s", config.AuthDatabase)
+// NewWithConfig connects to the mongodb of the config, it exits if the
+// connection fails.
+//
+// Deprecated: use ConnectWithConfig, which returns the error.
+func NewWithConfig(config db.Config, opts ...Option) db.MongoContext {
+	database, err := ConnectWithConfig(config, opts...)
+	if err != nil {
+		logger.Fatal(err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(connectionURI))
+	return database
+}
 
+// New connects to the mongodb of the connection string, it exits if the
+// connection fails.
+//
+// Deprecated: use Connect, which returns the error.
+func New(uri string, opts ...Option) db.MongoContext {
+	database, err := Connect(uri, opts...)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	err = client.Ping(ctx, readpref.Primary())
+	return database
+}
+
+// ConnectWithConfig connects to the mongodb of the config and pings the primary,
+// the error of the connection is returned.
+func ConnectWithConfig(config db.Config, opts ...Option) (db.MongoContext, error) {
+	tlsCfg, err := tlsConfig(config)
 	if err != nil {
-		logger.Fatal(err)
+		return nil, err
 	}
 
-	logger.Info("Mongodb connected")
-	return &mongodb{conn: client.Database(config.Database)}
+	clientOpts := options.Client().ApplyURI(connectionURI(config))
+	if tlsCfg != nil {
+		clientOpts.SetTLSConfig(tlsCfg)
+	}
+
+	return connect(clientOpts, config.Database, connectionTimeout(config), opts...)
 }
 
-func New(uri string) *mongodb {
+// Connect connects to the mongodb of the connection string and pings the
+// primary, the database is the one of the connection string. The error of the
+// connection is returned.
+func Connect(uri string, opts ...Option) (db.MongoContext, error) {
 	dbname := ""
 	temp := strings.Split(uri, "/")
 	if len(temp) == 4 {
 		dbname = strings.Split(temp[3], "?")[0]
 	}
 
+	clientOpts := options.Client().ApplyURI(uri)
+	timeout := defaultConnectionTimeout
+	if clientOpts.ConnectTimeout != nil && *clientOpts.ConnectTimeout > 0 {
+		timeout = *clientOpts.ConnectTimeout
+	}
+
+	return connect(clientOpts, dbname, timeout, opts...)
+}
+
+// connect connects the client and pings the primary within the timeout
+func connect(clientOpts *options.ClientOptions, dbname string, timeout time.Duration, opts ...Option) (*mongodb, error) {
 	logger.Info("Connecting mongodb, database: ", dbname)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	opts := options.Client().ApplyURI(uri)
-	client, err := mongo.Connect(ctx, opts)
-
+	client, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
-		logger.Fatal(err)
+		return nil, err
 	}
 
 	err = client.Ping(ctx, readpref.Primary())
 	if err != nil {
-		logger.Fatal(err)
+		client.Disconnect(context.Background())
+		return nil, err
 	}
 
 	logger.Info("Mongodb connected")
-	return &mongodb{conn: client.Database(dbname)}
+	opt := getOption(opts...)
+	return &mongodb{conn: client.Database(dbname), timeout: opt.timeout, opt: opt}, nil
+}
+
+// Ping checks the connection by sending a ping to the primary.
+func (db *mongodb) Ping(ctx context.Context) error {
+	ctx, cancel := db.context(ctx)
+	defer cancel()
+
+	return db.conn.Client().Ping(ctx, readpref.Primary())
+}
+
+// Close disconnects the client, the operations in progress are waited for until
+// ctx is done. The database cannot be used after.
+func (db *mongodb) Close(ctx context.Context) error {
+	if db.session != nil {
+		return ErrCloseInTransaction
+	}
+
+	return db.conn.Client().Disconnect(ctx)
+}
+
+// background returns the context of the operations without context, which is
+// the context of the transaction in a transaction
+func (db *mongodb) background() context.Context {
+	if db.ctx != nil {
+		return db.ctx
+	}
+
+	return context.Background()
+}
+
+// sessionContext returns ctx with the session in a transaction
+func (db *mongodb) sessionContext(ctx context.Context) context.Context {
+	if db.session != nil {
+		return mongo.NewSessionContext(ctx, db.session)
+	}
+
+	return ctx
+}
+
+// context applies the default timeout to ctx if it has no deadline, and the
+// session in a transaction
+func (db *mongodb) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = db.sessionContext(ctx)
+	if _, ok := ctx.Deadline(); ok || db.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, db.timeout)
+}
+
+// sortOf converts the sort to a sort document of mongo-driver
+func sortOf(sort db.Sort) bson.D {
+	var doc bson.D
+	for _, field := range sort {
+		if strings.HasPrefix(field, "-") {
+			doc = append(doc, bson.E{Key: field[1:], Value: -1})
+		} else {
+			doc = append(doc, bson.E{Key: strings.TrimPrefix(field, "+"), Value: 1})
+		}
+	}
+
+	return doc
+}
+
+// parseToken decodes a continuation token of the keyset, the token is nil if
+// cursor is empty
+func parseToken(k keyset, cursor string) (*token, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	b, err := decodeToken(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var t token
+	if err := bson.Unmarshal(b, &t); err != nil || !k.valid(&t) {
+		return nil, db.ErrInvalidCursor
+	}
+
+	return &t, nil
+}
+
+func formatToken(t token) (string, error) {
+	b, err := bson.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	return encodeToken(b), nil
+}
+
+// isReplacement reports whether the payload of an update is a replacement
+// document, whose first key is not an operator. A payload which is not a
+// document, e.g. a pipeline, is left to the driver.
+func isReplacement(payload interface{}) bool {
+	raw, err := bson.Marshal(payload)
+	if err != nil {
+		return false
+	}
+
+	elem, err := bson.Raw(raw).IndexErr(0)
+	if err != nil {
+		return false
+	}
+
+	return !strings.HasPrefix(elem.Key(), "$")
+}
+
+// keyValues returns the values of the keyset fields of a raw document, a
+// missing or null field is an error
+func keyValues(k keyset) func(doc interface{}) ([]interface{}, error) {
+	return func(doc interface{}) ([]interface{}, error) {
+		raw := doc.(bson.Raw)
+		values := make([]interface{}, len(k.fields))
+		for i, field := range k.fields {
+			value, err := raw.LookupErr(strings.Split(field, ".")...)
+			if err != nil || value.Type == bsontype.Null || value.Type == bsontype.Undefined {
+				return nil, missingKey(field)
+			}
+			values[i] = value
+		}
+
+		return values, nil
+	}
 }
 
 // FindOne executes the query and unmarshals the first obtained document into the
-// result argument, using the provided collection name, query and sort.
+// result argument, using the provided collection name, query and find options.
 // The query may be a map or a struct value capable of being marshalled with bson.
-// The sort is field name need to sort, a field name may be prefixed by - (minus) for
-// it to be sorted in reverse order.
-func (db *mongodb) FindOne(collectionName string, filter map[string]interface{}, sort interface{}, result interface{}) (err error) {
-	collection := db.conn.Collection(collectionName)
-	ctx := context.TODO()
+// The options may be nil, the first document in the sort order is returned.
+func (db *mongodb) FindOne(collectionName string, filter db.Filter, opts *db.FindOptions, result interface{}) (err error) {
+	return db.FindOneContext(db.background(), collectionName, filter, opts, result)
+}
 
-	opts := options.FindOne()
-	if sort != nil {
-		opts.SetSort(sort)
-	}
+// FindOneContext executes FindOne with the context.
+func (db *mongodb) FindOneContext(ctx context.Context, collectionName string, filter db.Filter, opts *db.FindOptions, result interface{}) (err error) {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(ctx)
+	defer cancel()
 
-	err = collection.FindOne(ctx, filter).Decode(result)
+	err = collection.FindOne(ctx, filter, findOneOptions(opts)).Decode(result)
 	if err != nil {
-		return err
+		return translateErr(err, collectionName, "FindOne")
 	}
 
 	return nil
 }
 
 // FindMany executes the query and unmarshals the all obtained document into the
-// result argument, using the provided collection name, query, sort, and result interface.
+// result argument, using the provided collection name, query, find options, and result interface.
 // The query may be a map or a struct value capable of being marshalled with bson.
-// The sort is field name need to sort, a field name may be prefixed by - (minus) for
-// it to be sorted in reverse order.
-func (db *mongodb) FindMany(collectionName string, filter map[string]interface{}, sort interface{}, results interface{}) (err error) {
+// The options may be nil.
+func (db *mongodb) FindMany(collectionName string, filter db.Filter, opts *db.FindOptions, results interface{}) (err error) {
+	return db.FindManyContext(db.background(), collectionName, filter, opts, results)
+}
+
+// FindManyContext executes FindMany with the context.
+func (db *mongodb) FindManyContext(ctx context.Context, collectionName string, filter db.Filter, opts *db.FindOptions, results interface{}) (err error) {
 	collection := db.conn.Collection(collectionName)
-	ctx := context.TODO()
+	ctx, cancel := db.context(ctx)
+	defer cancel()
 
-	opts := options.Find()
-	if sort != nil {
-		opts.SetSort(sort)
+	cur, err := collection.Find(ctx, filter, findOptions(opts))
+	if err != nil {
+		return translateErr(err, collectionName, "FindMany")
+	}
+	// Close the cursor once finished
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, results)
+	if err != nil {
+		return translateErr(err, collectionName, "FindMany")
+	}
+
+	if err := cur.Err(); err != nil {
+		return translateErr(err, collectionName, "FindMany")
+	}
+
+	return nil
+}
+
+// FindManyPaging executes FindMany function but skip by page parameter and limit by limit parameter.
+// A page of no documents is not an error, the total is not counted with
+// paging.WithoutTotal.
+func (db *mongodb) FindManyPaging(collectionName string, filter db.Filter, opts *db.FindOptions, page int, limit int, results interface{}, pagingOpts ...paging.Option) (*paging.Paging, error) {
+	return db.FindManyPagingContext(db.background(), collectionName, filter, opts, page, limit, results, pagingOpts...)
+}
+
+// FindManyPagingContext executes FindManyPaging with the context.
+func (db *mongodb) FindManyPagingContext(ctx context.Context, collectionName string, filter db.Filter, opts *db.FindOptions, page int, limit int, results interface{}, pagingOpts ...paging.Option) (*paging.Paging, error) {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(ctx)
+	defer cancel()
+
+	total := paging.UnknownTotal
+	if paging.CountTotal(pagingOpts...) {
+		count, err := collection.CountDocuments(ctx, filter, countOptions(opts))
+		if err != nil {
+			return nil, translateErr(err, collectionName, "FindManyPaging")
+		}
+		total = int(count)
 	}
+	pagingObj := paging.New(page, limit, total, pagingOpts...)
 
-	cur, err := collection.Find(ctx, filter, opts)
+	findOpts := findOptions(opts)
+	findOpts.SetLimit(int64(readLimit(pagingObj)))
+	findOpts.SetSkip(int64(pagingObj.Skip))
+
+	cur, err := collection.Find(ctx, filter, findOpts)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "FindManyPaging")
 	}
+	// Close the cursor once finished
+	defer cur.Close(ctx)
+
 	err = cur.All(ctx, results)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "FindManyPaging")
 	}
 
 	if err := cur.Err(); err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "FindManyPaging")
 	}
+	trimPage(pagingObj, results)
 
-	// Close the cursor once finished
-	cur.Close(context.TODO())
+	return pagingObj, nil
+}
 
-	return nil
+// FindManyCursor executes the query and unmarshals a page of limit documents
+// into the result argument, the page starts after the continuation token of the
+// previous call or at the first document if cursor is empty. The documents are
+// ordered by the sort and _id, the tokens of the pages after and before are
+// returned. Unlike FindManyPaging the documents are not counted nor skipped. The
+// sort fields must be set in every document, a page ending with a document
+// missing one is a BadRequest error.
+func (db *mongodb) FindManyCursor(collectionName string, filter db.Filter, sort db.Sort, cursor string, limit int, results interface{}) (*db.Cursor, error) {
+	return db.FindManyCursorContext(db.background(), collectionName, filter, sort, cursor, limit, results)
 }
 
-// FindMany executes FindMany function but skip by page parameter and limit by limit parameter
-func (db *mongodb) FindManyPaging(collectionName string, filter map[string]interface{}, sort interface{}, page int, limit int, results interface{}) (*paging.Paging, error) {
+// FindManyCursorContext executes FindManyCursor with the context.
+func (db *mongodb) FindManyCursorContext(ctx context.Context, collectionName string, filter db.Filter, sort db.Sort, cursor string, limit int, results interface{}) (*db.Cursor, error) {
 	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(ctx)
+	defer cancel()
+
+	k := keysetOf(sort)
+	t, err := parseToken(k, cursor)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "FindManyCursor")
+	}
+	limit = cursorLimit(limit)
 
 	opts := options.Find()
-	if sort != nil {
-		opts.SetSort(sort)
+	opts.SetSort(sortOf(k.sort(t != nil && t.Backward)))
+	opts.SetLimit(int64(limit + 1))
+
+	cur, err := collection.Find(ctx, k.filter(filter, t), opts)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "FindManyCursor")
+	}
+	defer cur.Close(ctx)
+
+	var raws []bson.Raw
+	err = cur.All(ctx, &raws)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "FindManyCursor")
 	}
-	opts.SetLimit(int64(limit))
-	opts.SetSkip(int64((page - 1) * limit))
 
-	ctx := context.TODO()
-	total, err := collection.CountDocuments(ctx, filter)
-	pagingObj := paging.New(page, limit, int(total))
+	docs := make([]interface{}, len(raws))
+	for i, raw := range raws {
+		docs[i] = raw
+	}
 
-	// Passing bson.D{{}} as the filter matches all documents in the collection
-	cur, err := collection.Find(ctx, filter, opts)
+	docs, c, err := cursorPage(k, t, docs, limit, keyValues(k), formatToken)
 	if err != nil {
-		return nil, err
+		return nil, translateErr(err, collectionName, "FindManyCursor")
 	}
 
-	err = cur.All(ctx, results)
+	err = decodeInto(docs, results, func(doc interface{}, v interface{}) error {
+		return bson.Unmarshal(doc.(bson.Raw), v)
+	})
 	if err != nil {
-		return nil, err
+		return nil, translateErr(err, collectionName, "FindManyCursor")
 	}
 
-	if err := cur.Err(); err != nil {
-		return nil, err
+	return c, nil
+}
+
+// Iterate executes the query and calls fn for each obtained document, decode
+// unmarshals the document into v. The documents are read by batches, so they
+// are not all loaded in memory, and the cursor is closed at the end. Iterate
+// stops at the first error of fn, which is returned unless it is
+// database.ErrStopIteration. The default timeout is not applied, ctx should be
+// cancelled to stop a long iteration.
+func (db *mongodb) Iterate(ctx context.Context, collectionName string, filter db.Filter, opts *db.IterOptions, fn func(decode func(v interface{}) error) error) error {
+	collection := db.conn.Collection(collectionName)
+	ctx = db.sessionContext(ctx)
+
+	findOpts := options.Find()
+	if opts != nil {
+		findOpts = findOptions(&opts.FindOptions)
+		if opts.BatchSize > 0 {
+			findOpts.SetBatchSize(int32(opts.BatchSize))
+		}
 	}
 
-	// Close the cursor once finished
-	cur.Close(context.TODO())
+	cur, err := collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return translateErr(err, collectionName, "Iterate")
+	}
+	defer cur.Close(context.Background())
 
-	return pagingObj, nil
+	for cur.Next(ctx) {
+		if err = fn(cur.Decode); err != nil {
+			return iterateErr(err)
+		}
+	}
+
+	return translateErr(cur.Err(), collectionName, "Iterate")
+}
+
+// EnsureIndex ensures an index with the given collection name and key exists, creating it with
+// the provided parameters if necessary. EnsureIndex does not modify a previously
+// existent index with a matching key. The old index must be dropped first instead.
+func (db *mongodb) EnsureIndex(collectionName string, index db.IndexConfig) bool {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(db.background())
+	defer cancel()
+
+	_, err := collection.Indexes().CreateOne(ctx, indexModel(index))
+	if err != nil {
+		logger.Error("[EnsureIndex] Ensure index name fail: ", index.Name, " - ERROR: ", err)
+		return false
+	}
+	logger.Info("[EnsureIndex] Successful to ensure index: ", index.Name)
+	return true
+}
+
+// DropIndex removes the index with the provided collection name and index name.
+func (db *mongodb) DropIndex(collectionName string, name string) bool {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(db.background())
+	defer cancel()
+
+	_, err := collection.Indexes().DropOne(ctx, name)
+	if err != nil {
+		logger.Error("[DropIndex] Drop index fail: ", name, " - Error: ", err)
+		return false
+	}
+	logger.Info("[DropIndex] Drop index success: ", name)
+	return true
+}
+
+// DropCollection drops the collection with its indexes, a missing collection is
+// not an error.
+func (db *mongodb) DropCollection(collectionName string) error {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(db.background())
+	defer cancel()
+
+	return translateErr(collection.Drop(ctx), collectionName, "DropCollection")
+}
+
+// ListIndexes returns the indexes of the provided collection name.
+func (db *mongodb) ListIndexes(collectionName string) ([]db.IndexConfig, error) {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(db.background())
+	defer cancel()
+
+	cur, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "ListIndexes")
+	}
+	defer cur.Close(ctx)
+
+	var specs indexSpecs
+	err = cur.All(ctx, &specs)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "ListIndexes")
+	}
+
+	return specs.indexConfigs(), nil
+}
+
+// PipeAll prepares a pipeline to aggregate. The pipeline document
+// must be a slice built in terms of the aggregation framework language.
+func (db *mongodb) PipeAll(collectionName string, pipeline interface{}, results interface{}) (err error) {
+	return db.PipeAllContext(db.background(), collectionName, pipeline, results)
+}
+
+// PipeAllContext runs the aggregation pipeline and unmarshals all obtained documents
+// into the result argument. The pipeline must be a slice of stages.
+func (db *mongodb) PipeAllContext(ctx context.Context, collectionName string, pipeline interface{}, results interface{}) (err error) {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(ctx)
+	defer cancel()
+
+	cur, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return translateErr(err, collectionName, "PipeAll")
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, results)
+	if err != nil {
+		return translateErr(err, collectionName, "PipeAll")
+	}
+
+	return nil
 }
 
 // Insert inserts one document in the respective collection, the returned error will
 // be an error.
-func (db *mongodb) InsertOne(collectionName string, payload interface{}) (err error) {
+func (db *mongodb) InsertOne(collectionName string, payload interface{}) (*db.InsertOneResult, error) {
+	return db.InsertOneContext(db.background(), collectionName, payload)
+}
+
+// InsertOneContext executes InsertOne with the context.
+func (db *mongodb) InsertOneContext(ctx context.Context, collectionName string, payload interface{}) (*db.InsertOneResult, error) {
 	collection := db.conn.Collection(collectionName)
-	ctx := context.TODO()
+	ctx, cancel := db.context(ctx)
+	defer cancel()
 
-	_, err = collection.InsertOne(ctx, payload)
+	result, err := collection.InsertOne(ctx, payload)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "InsertOne")
 	}
 
-	return nil
+	return insertOneResult(result), nil
 }
 
 // InsertMany queues up the provided documents for insertion and run insert.
-func (db *mongodb) InsertMany(collectionName string, payload []interface{}) (err error) {
+func (db *mongodb) InsertMany(collectionName string, payload []interface{}) (*db.InsertManyResult, error) {
+	return db.InsertManyContext(db.background(), collectionName, payload)
+}
+
+// InsertManyContext executes InsertMany with the context.
+func (db *mongodb) InsertManyContext(ctx context.Context, collectionName string, payload []interface{}) (*db.InsertManyResult, error) {
 	collection := db.conn.Collection(collectionName)
-	ctx := context.TODO()
+	ctx, cancel := db.context(ctx)
+	defer cancel()
 
-	_, err = collection.InsertMany(ctx, payload)
+	result, err := collection.InsertMany(ctx, payload)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "InsertMany")
 	}
 
-	return nil
+	return insertManyResult(result), nil
+}
+
+// Upsert finds a single document matching the provided selector document
+// and modifies it according to the update document.
+func (db *mongodb) Upsert(collectionName string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return db.UpsertContext(db.background(), collectionName, selector, payload)
+}
+
+// UpsertContext finds a single document matching the provided selector document
+// and modifies it according to the update document, or replaces it by a document
+// without operators as mgo does. The document is inserted if no document
+// matches.
+func (db *mongodb) UpsertContext(ctx context.Context, collectionName string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(ctx)
+	defer cancel()
+
+	var result *mongo.UpdateResult
+	var err error
+	if isReplacement(payload) {
+		result, err = collection.ReplaceOne(ctx, selector, payload, options.Replace().SetUpsert(true))
+	} else {
+		result, err = collection.UpdateOne(ctx, selector, payload, options.Update().SetUpsert(true))
+	}
+	if err != nil {
+		return nil, translateErr(err, collectionName, "Upsert")
+	}
+
+	return updateResult(result), nil
 }
 
 // UpdateOne finds a single document matching the provided selector document
 // and modifies it according to the update document.
-func (db *mongodb) UpdateOne(collectionName string, filter interface{}, payload interface{}) (err error) {
+func (db *mongodb) UpdateOne(collectionName string, filter db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return db.UpdateOneContext(db.background(), collectionName, filter, payload)
+}
+
+// UpdateOneContext executes UpdateOne with the context, a document without
+// operators replaces the matched document as with mgo.
+func (db *mongodb) UpdateOneContext(ctx context.Context, collectionName string, filter db.Filter, payload interface{}) (*db.UpdateResult, error) {
 	collection := db.conn.Collection(collectionName)
-	ctx := context.TODO()
+	ctx, cancel := db.context(ctx)
+	defer cancel()
 
-	_, err = collection.UpdateOne(ctx, filter, payload)
+	var result *mongo.UpdateResult
+	var err error
+	if isReplacement(payload) {
+		result, err = collection.ReplaceOne(ctx, filter, payload)
+	} else {
+		result, err = collection.UpdateOne(ctx, filter, payload)
+	}
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "UpdateOne")
 	}
 
-	return nil
+	return updateResult(result), nil
 }
 
 // UpdateMany finds all documents matching the provided selector document
 // and modifies them according to the update document.
-func (db *mongodb) UpdateMany(collectionName string, selector interface{}, payload interface{}) (err error) {
+func (db *mongodb) UpdateMany(collectionName string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return db.UpdateManyContext(db.background(), collectionName, selector, payload)
+}
+
+// UpdateManyContext executes UpdateMany with the context.
+func (db *mongodb) UpdateManyContext(ctx context.Context, collectionName string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
 	collection := db.conn.Collection(collectionName)
-	ctx := context.TODO()
+	ctx, cancel := db.context(ctx)
+	defer cancel()
 
-	_, err = collection.UpdateMany(ctx, selector, payload)
+	result, err := collection.UpdateMany(ctx, selector, payload)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "UpdateMany")
 	}
 
-	return nil
+	return updateResult(result), nil
 }
 
 // DeleteOne finds a single document matching the provided selector document
 // and removes it from the database.
-func (db *mongodb) DeleteOne(collectionName string, filter interface{}) (err error) {
+func (db *mongodb) DeleteOne(collectionName string, filter db.Filter) (*db.DeleteResult, error) {
+	return db.DeleteOneContext(db.background(), collectionName, filter)
+}
+
+// DeleteOneContext executes DeleteOne with the context.
+func (db *mongodb) DeleteOneContext(ctx context.Context, collectionName string, filter db.Filter) (*db.DeleteResult, error) {
 	collection := db.conn.Collection(collectionName)
-	ctx := context.TODO()
+	ctx, cancel := db.context(ctx)
+	defer cancel()
 
-	_, err = collection.DeleteOne(ctx, filter)
+	result, err := collection.DeleteOne(ctx, filter)
 	if err != nil {
-		return err
+		return nil, translateErr(err, collectionName, "DeleteOne")
 	}
 
-	return nil
+	return deleteResult(result.DeletedCount), nil
 }
 
 // DeleteMany finds all documents matching the provided selector document
 // and removes them from the database.
-func (db *mongodb) DeleteMany(collectionName string, selector interface{}) (err error) {
+func (db *mongodb) DeleteMany(collectionName string, selector db.Filter) (*db.DeleteResult, error) {
+	return db.DeleteManyContext(db.background(), collectionName, selector)
+}
+
+// DeleteManyContext executes DeleteMany with the context.
+func (db *mongodb) DeleteManyContext(ctx context.Context, collectionName string, selector db.Filter) (*db.DeleteResult, error) {
 	collection := db.conn.Collection(collectionName)
-	ctx := context.TODO()
+	ctx, cancel := db.context(ctx)
+	defer cancel()
 
-	_, err = collection.DeleteMany(ctx, selector)
+	result, err := collection.DeleteMany(ctx, selector)
+	if err != nil {
+		return nil, translateErr(err, collectionName, "DeleteMany")
+	}
+
+	return deleteResult(result.DeletedCount), nil
+}
+
+// BulkWrite runs the mixed insert, update, replace and delete operations, by
+// chunks of database.BulkChunkSize. In ordered mode the operations run in order
+// and stop at the first error, otherwise all operations are tried. The errors of
+// operations are returned as *database.BulkWriteError with the partial result.
+func (db *mongodb) BulkWrite(collectionName string, ops []db.WriteModel, ordered bool) (*db.BulkWriteResult, error) {
+	return db.BulkWriteContext(db.background(), collectionName, ops, ordered)
+}
+
+// BulkWriteContext executes BulkWrite with the context.
+func (db *mongodb) BulkWriteContext(ctx context.Context, collectionName string, ops []db.WriteModel, ordered bool) (*db.BulkWriteResult, error) {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(ctx)
+	defer cancel()
+
+	result, err := bulkWrite(ops, ordered, driverBulkRunner(ctx, collection, ordered))
+	return result, translateErr(err, collectionName, "BulkWrite")
+}
+
+// ApplyDB runs the findAndModify command, which updates a document matching
+// the selector and atomically returns the new version of the document.
+func (db *mongodb) ApplyDB(collectionName string, selector db.Filter, payload interface{}, result interface{}) (err error) {
+	return db.ApplyDBContext(db.background(), collectionName, selector, payload, result)
+}
+
+// ApplyDBContext runs the findAndModify command, which updates a document matching
+// the selector and atomically returns the new version of the document. A
+// document without operators replaces the matched document as with mgo.
+func (db *mongodb) ApplyDBContext(ctx context.Context, collectionName string, selector db.Filter, payload interface{}, result interface{}) (err error) {
+	collection := db.conn.Collection(collectionName)
+	ctx, cancel := db.context(ctx)
+	defer cancel()
+
+	if isReplacement(payload) {
+		opts := options.FindOneAndReplace().SetReturnDocument(options.After)
+		err = collection.FindOneAndReplace(ctx, selector, payload, opts).Decode(result)
+	} else {
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err = collection.FindOneAndUpdate(ctx, selector, payload, opts).Decode(result)
+	}
 	if err != nil {
-		return err
+		return translateErr(err, collectionName, "ApplyDB")
 	}
 
 	return nil
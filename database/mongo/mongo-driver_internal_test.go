@@ -0,0 +1,29 @@
+package mongo
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+func TestIsReplacement(t *testing.T) {
+	tests := []struct {
+		payload interface{}
+		want    bool
+	}{
+		{db.Filter{"$set": db.Filter{"name": "Dell"}}, false},
+		{bson.D{{Key: "$inc", Value: bson.M{"rank": 1}}}, false},
+		{db.Filter{"name": "Dell"}, true},
+		{&struct{ Name string }{"Dell"}, true},
+		{[]db.Filter{{"$set": db.Filter{"name": "Dell"}}}, false},
+		{db.Filter{}, false},
+	}
+
+	for _, tt := range tests {
+		if got := isReplacement(tt.payload); got != tt.want {
+			t.Errorf("isReplacement(%v) = %v, want %v", tt.payload, got, tt.want)
+		}
+	}
+}
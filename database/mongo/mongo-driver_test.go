@@ -0,0 +1,19 @@
+package mongo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quangdangfit/gosdk/database/mongo"
+	"github.com/quangdangfit/gosdk/database/mongotest"
+)
+
+func TestMongoDriver(t *testing.T) {
+	database, err := mongo.Connect(testURI(t))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer database.Close(context.Background())
+
+	mongotest.Run(t, database)
+}
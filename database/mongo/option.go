@@ -0,0 +1,68 @@
+package mongo
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	timeout time.Duration
+
+	txReadConcern  string
+	txWriteConcern string
+	txRetries      int
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithTimeout sets the default timeout of each operation, it is applied when the
+// context of operation has no deadline. Zero means no timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return optionFn(func(opt *option) {
+		opt.timeout = timeout
+	})
+}
+
+// WithTxReadConcern sets the read concern of transactions, e.g. "local",
+// "majority" or "snapshot". The default is the read concern of the client.
+func WithTxReadConcern(level string) Option {
+	return optionFn(func(opt *option) {
+		opt.txReadConcern = level
+	})
+}
+
+// WithTxWriteConcern sets the write concern of transactions, w is "majority", a
+// number of nodes, e.g. "1", or a tag set name. The default is the write concern
+// of the client.
+func WithTxWriteConcern(w string) Option {
+	return optionFn(func(opt *option) {
+		opt.txWriteConcern = w
+	})
+}
+
+// WithTxRetries sets how many times a transaction is retried on a transient
+// error, and its commit on an unknown commit result. Default is 3.
+func WithTxRetries(retries int) Option {
+	return optionFn(func(opt *option) {
+		opt.txRetries = retries
+	})
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{
+		txRetries: defaultTxRetries,
+	}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+
+	return &opt
+}
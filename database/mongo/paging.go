@@ -0,0 +1,31 @@
+package mongo
+
+import (
+	"reflect"
+
+	"github.com/quangdangfit/gosdk/utils/paging"
+)
+
+// readLimit returns the number of documents to read for the page, one more than
+// the limit if the total is unknown so that HasNext can be set
+func readLimit(p *paging.Paging) int {
+	if p.Total == paging.UnknownTotal {
+		return p.Limit + 1
+	}
+
+	return p.Limit
+}
+
+// trimPage sets HasNext of a page with unknown total and removes the extra
+// document from the slice pointed by results
+func trimPage(p *paging.Paging, results interface{}) {
+	if p.Total != paging.UnknownTotal {
+		return
+	}
+
+	slice := reflect.ValueOf(results).Elem()
+	if slice.Len() > p.Limit {
+		p.HasNext = true
+		slice.Set(slice.Slice(0, p.Limit))
+	}
+}
@@ -0,0 +1,106 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gopkg.in/mgo.v2"
+	mgobson "gopkg.in/mgo.v2/bson"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+// normalizeID converts the ObjectIDs of both drivers to database.ObjectID, so the
+// ids of results do not depend on the backend
+func normalizeID(id interface{}) interface{} {
+	switch v := id.(type) {
+	case primitive.ObjectID:
+		return db.ObjectID(v)
+	case mgobson.ObjectId:
+		var oid db.ObjectID
+		if len(v) == len(oid) {
+			copy(oid[:], v)
+			return oid
+		}
+	}
+
+	return id
+}
+
+func insertedOne(id interface{}) *db.InsertOneResult {
+	return &db.InsertOneResult{InsertedID: id}
+}
+
+func insertedMany(ids []interface{}) *db.InsertManyResult {
+	return &db.InsertManyResult{InsertedIDs: ids}
+}
+
+// changeResult converts the result of an update or upsert of mgo
+func changeResult(info *mgo.ChangeInfo) *db.UpdateResult {
+	result := &db.UpdateResult{
+		MatchedCount:  int64(info.Matched),
+		ModifiedCount: int64(info.Updated),
+	}
+	if info.UpsertedId != nil {
+		result.UpsertedCount = 1
+		result.UpsertedID = normalizeID(info.UpsertedId)
+	}
+
+	return result
+}
+
+func bulkUpdateResult(result *mgo.BulkResult) *db.UpdateResult {
+	return &db.UpdateResult{
+		MatchedCount:  int64(result.Matched),
+		ModifiedCount: int64(result.Modified),
+	}
+}
+
+func insertOneResult(result *mongo.InsertOneResult) *db.InsertOneResult {
+	return &db.InsertOneResult{InsertedID: normalizeID(result.InsertedID)}
+}
+
+func insertManyResult(result *mongo.InsertManyResult) *db.InsertManyResult {
+	ids := make([]interface{}, len(result.InsertedIDs))
+	for i, id := range result.InsertedIDs {
+		ids[i] = normalizeID(id)
+	}
+
+	return &db.InsertManyResult{InsertedIDs: ids}
+}
+
+func updateResult(result *mongo.UpdateResult) *db.UpdateResult {
+	return &db.UpdateResult{
+		MatchedCount:  result.MatchedCount,
+		ModifiedCount: result.ModifiedCount,
+		UpsertedCount: result.UpsertedCount,
+		UpsertedID:    normalizeID(result.UpsertedID),
+	}
+}
+
+func deleteResult(count int64) *db.DeleteResult {
+	return &db.DeleteResult{DeletedCount: count}
+}
+
+// withID returns the document as a bson.D of mgo with an _id, which is
+// generated if the document has none, and the _id
+func withID(payload interface{}) (mgobson.D, interface{}, error) {
+	data, err := mgobson.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc mgobson.D
+	err = mgobson.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, e := range doc {
+		if e.Name == "_id" {
+			return doc, normalizeID(e.Value), nil
+		}
+	}
+
+	id := db.NewObjectID()
+	return append(mgobson.D{{Name: "_id", Value: id}}, doc...), id, nil
+}
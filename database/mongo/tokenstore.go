@@ -0,0 +1,69 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/quangdangfit/gosdk/cache"
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+const tokenKeyPrefix = "resume_token:"
+
+type collectionTokenStore struct {
+	database   db.Mongo
+	collection string
+}
+
+type tokenDoc struct {
+	Name      string    `bson:"_id"`
+	Token     []byte    `bson:"token"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// NewCollectionTokenStore creates a store of resume tokens in the collection,
+// there is one document by watch name.
+func NewCollectionTokenStore(database db.Mongo, collection string) db.TokenStore {
+	return &collectionTokenStore{database: database, collection: collection}
+}
+
+func (s *collectionTokenStore) Load(name string) ([]byte, error) {
+	var docs []tokenDoc
+	err := s.database.FindMany(s.collection, db.Filter{"_id": name}, nil, &docs)
+	if err != nil || len(docs) == 0 {
+		return nil, err
+	}
+
+	return docs[0].Token, nil
+}
+
+func (s *collectionTokenStore) Save(name string, token []byte) error {
+	_, err := s.database.Upsert(s.collection, db.Filter{"_id": name},
+		db.Filter{"$set": db.Filter{"token": token, "updated_at": time.Now().UTC()}})
+	return err
+}
+
+type cacheTokenStore struct {
+	cache      cache.Cache
+	expiration time.Duration
+}
+
+// NewCacheTokenStore creates a store of resume tokens in the cache, a token
+// expires after the expiration, zero is the default expiration of the cache.
+func NewCacheTokenStore(c cache.Cache, expiration time.Duration) db.TokenStore {
+	return &cacheTokenStore{cache: c, expiration: expiration}
+}
+
+func (s *cacheTokenStore) Load(name string) ([]byte, error) {
+	var value string
+	err := s.cache.Get(tokenKeyPrefix+name, &value)
+	if err != nil || value == "" {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(value)
+}
+
+func (s *cacheTokenStore) Save(name string, token []byte) error {
+	return s.cache.SetWithExpiration(tokenKeyPrefix+name, base64.StdEncoding.EncodeToString(token), s.expiration)
+}
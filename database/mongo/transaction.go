@@ -0,0 +1,143 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+const (
+	defaultTxRetries = 3
+
+	transientTransactionError      = "TransientTransactionError"
+	unknownTransactionCommitResult = "UnknownTransactionCommitResult"
+)
+
+var (
+	// ErrTransactionNotSupported is returned by WithTransaction when the server is
+	// a standalone server, transactions require a replica set or a sharded cluster.
+	ErrTransactionNotSupported = errors.New("transactions are not supported by a standalone mongodb, a replica set or sharded cluster is required")
+
+	// ErrNestedTransaction is returned by WithTransaction of a transaction
+	ErrNestedTransaction = errors.New("nested transactions are not supported")
+
+	// ErrCloseInTransaction is returned by Close of a transaction, the connection
+	// is closed by the database the transaction was started with
+	ErrCloseInTransaction = errors.New("the connection cannot be closed in a transaction")
+)
+
+// WithTransaction runs fn in a multi-document transaction, the operations of tx
+// are part of the transaction, tx also implements database.MongoContext. The
+// transaction is committed if fn returns nil and aborted otherwise. fn is run
+// again on a transient transaction error, so it must not have other side
+// effects, and the commit is retried on an unknown commit result.
+func (db *mongodb) WithTransaction(ctx context.Context, fn func(tx db.Mongo) error) error {
+	if db.session != nil {
+		return ErrNestedTransaction
+	}
+
+	err := db.checkTransactionSupport(ctx)
+	if err != nil {
+		return err
+	}
+
+	session, err := db.conn.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	sessionCtx := mongo.NewSessionContext(ctx, session)
+	tx := &mongodb{conn: db.conn, timeout: db.timeout, opt: db.opt, session: session, ctx: sessionCtx}
+
+	for attempt := 0; ; attempt++ {
+		err = db.runTransaction(sessionCtx, session, tx, fn)
+		if err == nil {
+			return nil
+		}
+		if !hasErrorLabel(err, transientTransactionError) || attempt >= db.opt.txRetries || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// runTransaction runs fn in a transaction of the session and commits it
+func (db *mongodb) runTransaction(ctx mongo.SessionContext, session mongo.Session, tx *mongodb, fn func(tx db.Mongo) error) error {
+	err := session.StartTransaction(db.transactionOptions())
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		session.AbortTransaction(context.Background())
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = session.CommitTransaction(ctx)
+		if err == nil || !hasErrorLabel(err, unknownTransactionCommitResult) || attempt >= db.opt.txRetries || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+func (db *mongodb) transactionOptions() *options.TransactionOptions {
+	opts := options.Transaction()
+	if db.opt.txReadConcern != "" {
+		opts.SetReadConcern(readconcern.New(readconcern.Level(db.opt.txReadConcern)))
+	}
+	if db.opt.txWriteConcern != "" {
+		opts.SetWriteConcern(writeConcern(db.opt.txWriteConcern))
+	}
+
+	return opts
+}
+
+// checkTransactionSupport returns ErrTransactionNotSupported if the server is
+// neither a member of a replica set nor a mongos
+func (db *mongodb) checkTransactionSupport(ctx context.Context) error {
+	var result struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+
+	err := db.conn.Client().Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&result)
+	if err != nil {
+		return err
+	}
+	if result.SetName == "" && result.Msg != "isdbgrid" {
+		return ErrTransactionNotSupported
+	}
+
+	return nil
+}
+
+// writeConcern parses w, which is "majority", a number of nodes or a tag set name
+func writeConcern(w string) *writeconcern.WriteConcern {
+	if w == "majority" {
+		return writeconcern.New(writeconcern.WMajority())
+	}
+	if n, err := strconv.Atoi(w); err == nil {
+		return writeconcern.New(writeconcern.W(n))
+	}
+
+	return writeconcern.New(writeconcern.WTagSet(w))
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var labeled interface{ HasErrorLabel(string) bool }
+	if errors.As(err, &labeled) {
+		return labeled.HasErrorLabel(label)
+	}
+
+	return false
+}
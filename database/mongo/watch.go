@@ -0,0 +1,208 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/utils/logger"
+)
+
+const (
+	watchMinBackoff = time.Second
+	watchMaxBackoff = 30 * time.Second
+)
+
+// fatalChangeStreamCodes are the server errors a change stream cannot resume
+// from: Unauthorized, ChangeStreamFatalError, ChangeStreamHistoryLost and
+// change streams not supported by a standalone server
+var fatalChangeStreamCodes = map[int32]bool{
+	13:    true,
+	280:   true,
+	286:   true,
+	40573: true,
+}
+
+// changeDoc is a change event document of a change stream
+type changeDoc struct {
+	ID            bson.Raw `bson:"_id"`
+	OperationType string   `bson:"operationType"`
+	Ns            struct {
+		Coll string `bson:"coll"`
+	} `bson:"ns"`
+	DocumentKey       db.Filter `bson:"documentKey"`
+	UpdateDescription struct {
+		UpdatedFields db.Filter `bson:"updatedFields"`
+		RemovedFields []string  `bson:"removedFields"`
+	} `bson:"updateDescription"`
+	ClusterTime  primitive.Timestamp `bson:"clusterTime"`
+	FullDocument bson.Raw            `bson:"fullDocument"`
+}
+
+func (c *changeDoc) event() *db.ChangeEvent {
+	fullDocument := append([]byte(nil), c.FullDocument...)
+	return &db.ChangeEvent{
+		Operation:     c.OperationType,
+		Collection:    c.Ns.Coll,
+		DocumentKey:   c.DocumentKey,
+		UpdatedFields: c.UpdateDescription.UpdatedFields,
+		RemovedFields: c.UpdateDescription.RemovedFields,
+		ClusterTime:   time.Unix(int64(c.ClusterTime.T), 0).UTC(),
+		FullDocument:  fullDocument,
+		Token:         append([]byte(nil), c.ID...),
+		Decode: func(v interface{}) error {
+			if len(fullDocument) == 0 {
+				return db.ErrNoFullDocument
+			}
+			return bson.Unmarshal(fullDocument, v)
+		},
+	}
+}
+
+// Watch opens a change stream of the collection and calls handler for each
+// change matching the aggregation pipeline, the pipeline may be nil. The stream
+// is resumed after the last handled event when it fails with a transient error,
+// with a backoff up to 30 seconds. With a token store, the resume token of each
+// handled event is saved under the name of the watch, so a restarted watch
+// continues where it stopped; events are delivered at least once.
+//
+// Watch returns when ctx is done, handler returns an error, the stream fails
+// with a fatal error, or nil when the stream is invalidated, e.g. by dropping
+// the collection. Change streams require a replica set or a sharded cluster.
+func (db *mongodb) Watch(ctx context.Context, collectionName string, pipeline interface{}, handler db.ChangeHandler, opts *db.WatchOptions) error {
+	w, err := newWatcher(db.conn.Collection(collectionName), pipeline, handler, opts)
+	if err != nil {
+		return err
+	}
+
+	return w.run(ctx)
+}
+
+type watcher struct {
+	collection *mongo.Collection
+	pipeline   interface{}
+	handler    db.ChangeHandler
+	opts       *db.WatchOptions
+	token      []byte
+	backoff    time.Duration
+}
+
+func newWatcher(collection *mongo.Collection, pipeline interface{}, handler db.ChangeHandler, opts *db.WatchOptions) (*watcher, error) {
+	if opts == nil {
+		opts = &db.WatchOptions{}
+	}
+	if opts.Store != nil && opts.Name == "" {
+		return nil, errors.New("watch name is required with a token store")
+	}
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	w := &watcher{
+		collection: collection,
+		pipeline:   pipeline,
+		handler:    handler,
+		opts:       opts,
+		backoff:    watchMinBackoff,
+	}
+	if opts.Store != nil {
+		token, err := opts.Store.Load(opts.Name)
+		if err != nil {
+			return nil, err
+		}
+		w.token = token
+	}
+
+	return w, nil
+}
+
+// run watches and resumes the change stream with a backoff
+func (w *watcher) run(ctx context.Context) error {
+	for {
+		resumable, err := w.watch(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !resumable {
+			return err
+		}
+
+		logger.Warnf("[Watch] Change stream of %s failed, resuming in %s: %s", w.collection.Name(), w.backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.backoff):
+		}
+
+		w.backoff *= 2
+		if w.backoff > watchMaxBackoff {
+			w.backoff = watchMaxBackoff
+		}
+	}
+}
+
+// watch reads the change stream until it fails, it reports whether the watch can
+// be resumed after the error
+func (w *watcher) watch(ctx context.Context) (bool, error) {
+	opts := options.ChangeStream()
+	if w.opts.FullDocument {
+		opts.SetFullDocument(options.UpdateLookup)
+	}
+	if w.opts.BatchSize > 0 {
+		opts.SetBatchSize(int32(w.opts.BatchSize))
+	}
+	if w.opts.MaxAwaitTime > 0 {
+		opts.SetMaxAwaitTime(w.opts.MaxAwaitTime)
+	}
+	if len(w.token) > 0 {
+		opts.SetResumeAfter(bson.Raw(w.token))
+	}
+
+	stream, err := w.collection.Watch(ctx, w.pipeline, opts)
+	if err != nil {
+		return resumableChangeStreamErr(err), err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var change changeDoc
+		if err := stream.Decode(&change); err != nil {
+			return false, err
+		}
+
+		event := change.event()
+		if err := w.handler(ctx, event); err != nil {
+			return false, err
+		}
+
+		w.token = event.Token
+		w.backoff = watchMinBackoff
+		if w.opts.Store != nil {
+			if err := w.opts.Store.Save(w.opts.Name, w.token); err != nil {
+				logger.Error("[Watch] Failed to save resume token: ", err)
+			}
+		}
+
+		if event.Operation == "invalidate" {
+			return false, nil
+		}
+	}
+
+	err = stream.Err()
+	return resumableChangeStreamErr(err), err
+}
+
+func resumableChangeStreamErr(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return !fatalChangeStreamCodes[cmdErr.Code]
+	}
+
+	return true
+}
@@ -0,0 +1,29 @@
+package mongo
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+func TestChangeEventDecode(t *testing.T) {
+	raw, err := bson.Marshal(bson.M{"code": "dell"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	event := (&changeDoc{OperationType: "insert", FullDocument: raw}).event()
+	var doc struct {
+		Code string `bson:"code"`
+	}
+	if err := event.Decode(&doc); err != nil || doc.Code != "dell" {
+		t.Errorf("Decode = %+v, %v, want the full document", doc, err)
+	}
+
+	event = (&changeDoc{OperationType: "delete"}).event()
+	if err := event.Decode(&doc); err != db.ErrNoFullDocument {
+		t.Errorf("Decode without full document = %v, want ErrNoFullDocument", err)
+	}
+}
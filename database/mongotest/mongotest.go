@@ -0,0 +1,649 @@
+// Package mongotest provides a conformance suite for the implementations of
+// database.Mongo, so every backend behaves the same for the callers. Run it from
+// an integration test of the backend with a connected database:
+//
+//	func TestMongo(t *testing.T) {
+//		database, err := mongo.Connect("mongodb://localhost:27017/test")
+//		if err != nil {
+//			t.Skip(err)
+//		}
+//		defer database.Close(context.Background())
+//		mongotest.Run(t, database)
+//	}
+package mongotest
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/errors"
+	"github.com/quangdangfit/gosdk/utils/paging"
+)
+
+type brand struct {
+	Code string `bson:"code"`
+	Name string `bson:"name"`
+	Rank int    `bson:"rank"`
+}
+
+type suite struct {
+	database   db.Mongo
+	collection string
+	opt        *option
+}
+
+// Run runs the conformance suite against the database, the documents are written
+// to a temporary collection which is dropped before Run returns, so the database
+// may be closed right after. The options declare the features the backend does
+// not support.
+func Run(t *testing.T, database db.Mongo, opts ...Option) {
+	s := &suite{
+		database:   database,
+		collection: fmt.Sprintf("mongotest_%d", time.Now().UnixNano()),
+		opt:        getOption(opts...),
+	}
+	defer func() {
+		if err := database.DropCollection(s.collection); err != nil {
+			t.Errorf("DropCollection %s: %v", s.collection, err)
+		}
+	}()
+
+	t.Run("Ping", s.testPing)
+	t.Run("InsertOne", s.testInsertOne)
+	t.Run("InsertMany", s.testInsertMany)
+	t.Run("FindOne", s.testFindOne)
+	t.Run("FindMany", s.testFindMany)
+	t.Run("Collation", s.testCollation)
+	t.Run("FindManyPaging", s.testFindManyPaging)
+	t.Run("FindManyCursor", s.testFindManyCursor)
+	t.Run("Iterate", s.testIterate)
+	t.Run("PipeAll", s.testPipeAll)
+	t.Run("Upsert", s.testUpsert)
+	t.Run("UpdateOne", s.testUpdateOne)
+	t.Run("UpdateMany", s.testUpdateMany)
+	t.Run("DeleteOne", s.testDeleteOne)
+	t.Run("DeleteMany", s.testDeleteMany)
+	t.Run("BulkWrite", s.testBulkWrite)
+	t.Run("ApplyDB", s.testApplyDB)
+	t.Run("UpdateVersion", s.testUpdateVersion)
+	t.Run("Index", s.testIndex)
+}
+
+// seed replaces the documents of collection by n brands, the rank of brand i is i
+func (s *suite) seed(t *testing.T, n int) {
+	t.Helper()
+
+	if _, err := s.database.DeleteMany(s.collection, db.Filter{}); err != nil {
+		t.Fatalf("DeleteMany: %v", err)
+	}
+
+	if n == 0 {
+		return
+	}
+
+	payload := make([]interface{}, n)
+	for i := range payload {
+		payload[i] = brand{Code: fmt.Sprintf("code%d", i), Name: fmt.Sprintf("name%d", i), Rank: i}
+	}
+	result, err := s.database.InsertMany(s.collection, payload)
+	if err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+	if len(result.InsertedIDs) != n {
+		t.Fatalf("InsertMany got %d ids, want %d", len(result.InsertedIDs), n)
+	}
+}
+
+func (s *suite) count(t *testing.T, query db.Filter) int {
+	t.Helper()
+
+	var results []brand
+	if err := s.database.FindMany(s.collection, query, nil, &results); err != nil {
+		t.Fatalf("FindMany: %v", err)
+	}
+
+	return len(results)
+}
+
+func (s *suite) testInsertOne(t *testing.T) {
+	s.seed(t, 0)
+
+	inserted, err := s.database.InsertOne(s.collection, brand{Code: "dell", Name: "Dell"})
+	if err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	id, ok := inserted.InsertedID.(db.ObjectID)
+	if !ok || id.IsZero() {
+		t.Errorf("InsertOne got id %#v, want a generated ObjectID", inserted.InsertedID)
+	}
+
+	var result brand
+	err = s.database.FindOne(s.collection, db.Filter{"code": "dell"}, nil, &result)
+	if err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	if result.Name != "Dell" {
+		t.Errorf("FindOne got name %q, want %q", result.Name, "Dell")
+	}
+}
+
+func (s *suite) testInsertMany(t *testing.T) {
+	s.seed(t, 3)
+
+	if n := s.count(t, db.Filter{}); n != 3 {
+		t.Errorf("got %d documents, want 3", n)
+	}
+}
+
+func (s *suite) testPing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.database.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
+
+func (s *suite) testFindOne(t *testing.T) {
+	s.seed(t, 3)
+
+	var result brand
+	err := s.database.FindOne(s.collection, db.Filter{}, &db.FindOptions{Sort: db.Sort{"-rank"}}, &result)
+	if err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	if result.Rank != 2 {
+		t.Errorf("FindOne sorted by -rank got rank %d, want 2", result.Rank)
+	}
+
+	err = s.database.FindOne(s.collection, db.Filter{"code": "missing"}, nil, &result)
+	if err == nil {
+		t.Error("FindOne of missing document got no error")
+	}
+}
+
+func (s *suite) testFindMany(t *testing.T) {
+	s.seed(t, 3)
+
+	var results []brand
+	err := s.database.FindMany(s.collection, db.Filter{"rank": db.Filter{"$gte": 1}}, &db.FindOptions{Sort: db.Sort{"-rank"}}, &results)
+	if err != nil {
+		t.Fatalf("FindMany: %v", err)
+	}
+	if len(results) != 2 || results[0].Rank != 2 || results[1].Rank != 1 {
+		t.Errorf("FindMany got %+v, want ranks [2 1]", results)
+	}
+
+	results = nil
+	opts := &db.FindOptions{Sort: db.Sort{"rank"}, Projection: db.Filter{"code": 1}, Skip: 1, Limit: 1}
+	err = s.database.FindMany(s.collection, db.Filter{}, opts, &results)
+	if err != nil {
+		t.Fatalf("FindMany with options: %v", err)
+	}
+	if len(results) != 1 || results[0].Code != "code1" || results[0].Name != "" {
+		t.Errorf("FindMany with projection, skip and limit got %+v, want only the code of rank 1", results)
+	}
+}
+
+func (s *suite) testCollation(t *testing.T) {
+	s.seed(t, 0)
+	if _, err := s.database.InsertOne(s.collection, brand{Code: "dell", Name: "Dell"}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+
+	var results []brand
+	opts := &db.FindOptions{Collation: &db.Collation{Locale: "en", Strength: 2}}
+	err := s.database.FindMany(s.collection, db.Filter{"name": "DELL"}, opts, &results)
+	if !s.opt.collation {
+		if errors.GetType(err) != errors.BadRequest {
+			t.Errorf("FindMany with collation got %v, want a BadRequest error", err)
+		}
+		return
+	}
+
+	if err != nil {
+		t.Fatalf("FindMany with collation: %v", err)
+	}
+	if len(results) != 1 || results[0].Code != "dell" {
+		t.Errorf("FindMany case insensitive got %+v, want dell", results)
+	}
+}
+
+func (s *suite) testFindManyPaging(t *testing.T) {
+	s.seed(t, 5)
+
+	var results []brand
+	p, err := s.database.FindManyPaging(s.collection, db.Filter{}, &db.FindOptions{Sort: db.Sort{"rank"}}, 2, 2, &results)
+	if err != nil {
+		t.Fatalf("FindManyPaging: %v", err)
+	}
+	if p.Total != 5 || p.TotalPage != 3 || p.Current != 2 {
+		t.Errorf("FindManyPaging got paging %+v, want total 5, total page 3, current 2", p)
+	}
+	if len(results) != 2 || results[0].Rank != 2 || results[1].Rank != 3 {
+		t.Errorf("FindManyPaging got %+v, want ranks [2 3]", results)
+	}
+	if !p.HasNext || !p.HasPrev {
+		t.Errorf("FindManyPaging got paging %+v, want next and previous pages", p)
+	}
+
+	results = nil
+	p, err = s.database.FindManyPaging(s.collection, db.Filter{}, &db.FindOptions{Sort: db.Sort{"rank"}}, 3, 2, &results, paging.WithoutTotal())
+	if err != nil {
+		t.Fatalf("FindManyPaging without total: %v", err)
+	}
+	if p.Total != paging.UnknownTotal || p.HasNext || !p.HasPrev || len(results) != 1 || results[0].Rank != 4 {
+		t.Errorf("FindManyPaging without total got paging %+v and %+v, want the last page of rank 4", p, results)
+	}
+
+	results = nil
+	p, err = s.database.FindManyPaging(s.collection, db.Filter{"code": "missing"}, nil, 1, 2, &results)
+	if err != nil {
+		t.Fatalf("FindManyPaging of no documents: %v", err)
+	}
+	if p.Total != 0 || p.Current != 1 || p.Skip != 0 || p.HasNext || p.HasPrev || len(results) != 0 {
+		t.Errorf("FindManyPaging of no documents got paging %+v and %+v, want an empty page 1", p, results)
+	}
+}
+
+func (s *suite) testFindManyCursor(t *testing.T) {
+	s.seed(t, 5)
+
+	ranks := func(results []brand) []int {
+		r := make([]int, len(results))
+		for i, b := range results {
+			r[i] = b.Rank
+		}
+		return r
+	}
+
+	// Forward through all pages
+	var pages [][]int
+	var prev string
+	cursor := ""
+	for {
+		var results []brand
+		c, err := s.database.FindManyCursor(s.collection, db.Filter{}, db.Sort{"-rank"}, cursor, 2, &results)
+		if err != nil {
+			t.Fatalf("FindManyCursor: %v", err)
+		}
+		pages = append(pages, ranks(results))
+		if !c.HasNext() {
+			break
+		}
+		prev, cursor = c.Prev, c.Next
+	}
+	if got := fmt.Sprint(pages); got != "[[4 3] [2 1] [0]]" {
+		t.Fatalf("FindManyCursor got pages %s, want [[4 3] [2 1] [0]]", got)
+	}
+
+	// Backward from the second page
+	var results []brand
+	c, err := s.database.FindManyCursor(s.collection, db.Filter{}, db.Sort{"-rank"}, prev, 2, &results)
+	if err != nil {
+		t.Fatalf("FindManyCursor backward: %v", err)
+	}
+	if got := fmt.Sprint(ranks(results)); got != "[4 3]" || c.HasPrev() || !c.HasNext() {
+		t.Errorf("FindManyCursor backward got %s %+v, want [4 3] with only a next page", got, c)
+	}
+
+	_, err = s.database.FindManyCursor(s.collection, db.Filter{}, db.Sort{"name"}, cursor, 2, &results)
+	if err == nil {
+		t.Error("FindManyCursor with a token of another sort got no error")
+	}
+}
+
+func (s *suite) testIterate(t *testing.T) {
+	s.seed(t, 5)
+
+	var ranks []int
+	opts := &db.IterOptions{FindOptions: db.FindOptions{Sort: db.Sort{"-rank"}}, BatchSize: 2}
+	err := s.database.Iterate(context.Background(), s.collection, db.Filter{}, opts, func(decode func(v interface{}) error) error {
+		var result brand
+		if err := decode(&result); err != nil {
+			return err
+		}
+		ranks = append(ranks, result.Rank)
+		if len(ranks) == 3 {
+			return db.ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Iterate: %v", err)
+	}
+	if got := fmt.Sprint(ranks); got != "[4 3 2]" {
+		t.Errorf("Iterate stopped at the third document got %s, want [4 3 2]", got)
+	}
+
+	failure := fmt.Errorf("failure")
+	err = s.database.Iterate(context.Background(), s.collection, db.Filter{}, nil, func(decode func(v interface{}) error) error {
+		return failure
+	})
+	if err != failure {
+		t.Errorf("Iterate got error %v, want the error of the function", err)
+	}
+}
+
+func (s *suite) testPipeAll(t *testing.T) {
+	s.seed(t, 3)
+
+	pipeline := []db.Filter{
+		{"$match": db.Filter{"rank": db.Filter{"$lt": 2}}},
+		{"$sort": db.Filter{"rank": -1}},
+	}
+
+	var results []brand
+	err := s.database.PipeAll(s.collection, pipeline, &results)
+	if err != nil {
+		t.Fatalf("PipeAll: %v", err)
+	}
+	if len(results) != 2 || results[0].Rank != 1 {
+		t.Errorf("PipeAll got %+v, want ranks [1 0]", results)
+	}
+}
+
+func (s *suite) testUpsert(t *testing.T) {
+	s.seed(t, 0)
+
+	selector := db.Filter{"code": "asus"}
+	for i, name := range []string{"Asus", "ASUS"} {
+		result, err := s.database.Upsert(s.collection, selector, db.Filter{"$set": db.Filter{"name": name}})
+		if err != nil {
+			t.Fatalf("Upsert: %v", err)
+		}
+		if i == 0 && (result.UpsertedCount != 1 || result.UpsertedID == nil || result.MatchedCount != 0) {
+			t.Errorf("Upsert inserting got %+v, want 1 upserted", result)
+		}
+		if i == 1 && (result.UpsertedCount != 0 || result.MatchedCount != 1 || result.ModifiedCount != 1) {
+			t.Errorf("Upsert updating got %+v, want 1 matched and modified", result)
+		}
+	}
+
+	var results []brand
+	if err := s.database.FindMany(s.collection, selector, nil, &results); err != nil {
+		t.Fatalf("FindMany: %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "ASUS" {
+		t.Errorf("Upsert got %+v, want one document named ASUS", results)
+	}
+
+	// A document without operators is a replacement on every backend
+	selector = db.Filter{"code": "hp"}
+	for i, rank := range []int{1, 2} {
+		result, err := s.database.Upsert(s.collection, selector, &brand{Code: "hp", Name: "HP", Rank: rank})
+		if err != nil {
+			t.Fatalf("Upsert of a struct: %v", err)
+		}
+		if i == 0 && result.UpsertedCount != 1 {
+			t.Errorf("Upsert of a struct inserting got %+v, want 1 upserted", result)
+		}
+		if i == 1 && (result.MatchedCount != 1 || result.ModifiedCount != 1) {
+			t.Errorf("Upsert of a struct replacing got %+v, want 1 matched and modified", result)
+		}
+	}
+
+	results = nil
+	if err := s.database.FindMany(s.collection, selector, nil, &results); err != nil {
+		t.Fatalf("FindMany: %v", err)
+	}
+	if len(results) != 1 || results[0].Rank != 2 || results[0].Name != "HP" {
+		t.Errorf("Upsert of a struct got %+v, want one document of rank 2", results)
+	}
+
+	result, err := s.database.UpdateOne(s.collection, selector, brand{Code: "hp", Rank: 3})
+	if err != nil {
+		t.Fatalf("UpdateOne of a struct: %v", err)
+	}
+	if result.MatchedCount != 1 {
+		t.Errorf("UpdateOne of a struct got %+v, want 1 matched", result)
+	}
+}
+
+func (s *suite) testUpdateOne(t *testing.T) {
+	s.seed(t, 3)
+
+	update := db.Filter{"$set": db.Filter{"name": "updated"}}
+	result, err := s.database.UpdateOne(s.collection, db.Filter{"code": "code1"}, update)
+	if err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if result.MatchedCount != 1 || result.ModifiedCount != 1 {
+		t.Errorf("UpdateOne got %+v, want 1 matched and modified", result)
+	}
+
+	result, err = s.database.UpdateOne(s.collection, db.Filter{"code": "missing"}, update)
+	if err != nil {
+		t.Fatalf("UpdateOne of missing document: %v", err)
+	}
+	if result.MatchedCount != 0 {
+		t.Errorf("UpdateOne of missing document got %+v, want 0 matched", result)
+	}
+
+	if n := s.count(t, db.Filter{"name": "updated"}); n != 1 {
+		t.Errorf("UpdateOne updated %d documents, want 1", n)
+	}
+}
+
+func (s *suite) testUpdateMany(t *testing.T) {
+	s.seed(t, 3)
+
+	update := db.Filter{"$set": db.Filter{"name": "updated"}}
+	result, err := s.database.UpdateMany(s.collection, db.Filter{"rank": db.Filter{"$gte": 1}}, update)
+	if err != nil {
+		t.Fatalf("UpdateMany: %v", err)
+	}
+	if result.MatchedCount != 2 || result.ModifiedCount != 2 {
+		t.Errorf("UpdateMany got %+v, want 2 matched and modified", result)
+	}
+
+	if n := s.count(t, db.Filter{"name": "updated"}); n != 2 {
+		t.Errorf("UpdateMany updated %d documents, want 2", n)
+	}
+}
+
+func (s *suite) testDeleteOne(t *testing.T) {
+	s.seed(t, 3)
+
+	result, err := s.database.DeleteOne(s.collection, db.Filter{"code": "code1"})
+	if err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	if result.DeletedCount != 1 {
+		t.Errorf("DeleteOne got %+v, want 1 deleted", result)
+	}
+
+	result, err = s.database.DeleteOne(s.collection, db.Filter{"code": "missing"})
+	if err != nil {
+		t.Fatalf("DeleteOne of missing document: %v", err)
+	}
+	if result.DeletedCount != 0 {
+		t.Errorf("DeleteOne of missing document got %+v, want 0 deleted", result)
+	}
+
+	if n := s.count(t, db.Filter{}); n != 2 {
+		t.Errorf("got %d documents after DeleteOne, want 2", n)
+	}
+}
+
+func (s *suite) testDeleteMany(t *testing.T) {
+	s.seed(t, 3)
+
+	result, err := s.database.DeleteMany(s.collection, db.Filter{"rank": db.Filter{"$gte": 1}})
+	if err != nil {
+		t.Fatalf("DeleteMany: %v", err)
+	}
+	if result.DeletedCount != 2 {
+		t.Errorf("DeleteMany got %+v, want 2 deleted", result)
+	}
+
+	if n := s.count(t, db.Filter{}); n != 1 {
+		t.Errorf("got %d documents after DeleteMany, want 1", n)
+	}
+}
+
+func (s *suite) testBulkWrite(t *testing.T) {
+	s.seed(t, 3)
+
+	ops := []db.WriteModel{
+		db.InsertOneModel{Document: brand{Code: "hp", Name: "HP"}},
+		db.UpdateOneModel{Filter: db.Filter{"code": "code0"}, Update: db.Filter{"$set": db.Filter{"name": "updated"}}},
+		db.UpdateManyModel{Filter: db.Filter{"rank": db.Filter{"$gte": 1}}, Update: db.Filter{"$inc": db.Filter{"rank": 10}}},
+		db.DeleteOneModel{Filter: db.Filter{"code": "hp"}},
+	}
+	result, err := s.database.BulkWrite(s.collection, ops, true)
+	if err != nil {
+		t.Fatalf("BulkWrite: %v", err)
+	}
+	if result.InsertedCount != 1 || result.MatchedCount != 3 || result.ModifiedCount != 3 || result.DeletedCount != 1 {
+		t.Errorf("BulkWrite got %+v, want 1 inserted, 3 matched and modified, 1 deleted", result)
+	}
+
+	// The duplicate _id fails the second operation, which stops an ordered bulk
+	ops = []db.WriteModel{
+		db.InsertOneModel{Document: db.Filter{"_id": "dup"}},
+		db.InsertOneModel{Document: db.Filter{"_id": "dup"}},
+		db.InsertOneModel{Document: db.Filter{"_id": "after"}},
+	}
+	for _, ordered := range []bool{true, false} {
+		s.database.DeleteMany(s.collection, db.Filter{"_id": db.Filter{"$in": []string{"dup", "after"}}})
+
+		result, err = s.database.BulkWrite(s.collection, ops, ordered)
+		var bulkErr *db.BulkWriteError
+		if !stderrors.As(err, &bulkErr) || len(bulkErr.WriteErrors) != 1 || bulkErr.WriteErrors[0].Index != 1 {
+			t.Fatalf("BulkWrite ordered %v got error %v, want a write error of operation 1", ordered, err)
+		}
+		if errors.GetType(err) != errors.DuplicateError {
+			t.Errorf("BulkWrite ordered %v got error type %v, want DuplicateError", ordered, errors.GetType(err))
+		}
+		if n := s.count(t, db.Filter{"_id": "after"}); (n == 1) == ordered {
+			t.Errorf("BulkWrite ordered %v ran the operation after the error %d times", ordered, n)
+		}
+		inserted := int64(2)
+		if ordered {
+			inserted = 1
+		}
+		if result == nil || result.InsertedCount != inserted {
+			t.Errorf("BulkWrite ordered %v got %+v, want %d inserted", ordered, result, inserted)
+		}
+	}
+
+	// Changing the _id fails the second update, the others are run unordered
+	ops = []db.WriteModel{
+		db.UpdateOneModel{Filter: db.Filter{"code": "code0"}, Update: db.Filter{"$set": db.Filter{"name": "first"}}},
+		db.UpdateOneModel{Filter: db.Filter{"code": "code1"}, Update: db.Filter{"$set": db.Filter{"_id": "changed"}}},
+		db.UpdateOneModel{Filter: db.Filter{"code": "code2"}, Update: db.Filter{"$set": db.Filter{"name": "third"}}},
+	}
+	result, err = s.database.BulkWrite(s.collection, ops, false)
+	var bulkErr *db.BulkWriteError
+	if !stderrors.As(err, &bulkErr) || len(bulkErr.WriteErrors) != 1 || bulkErr.WriteErrors[0].Index != 1 {
+		t.Fatalf("BulkWrite of updates got error %v, want a write error of operation 1", err)
+	}
+	if n := s.count(t, db.Filter{"name": db.Filter{"$in": []string{"first", "third"}}}); n != 2 {
+		t.Errorf("BulkWrite of updates updated %d documents, want 2", n)
+	}
+	matched := int64(0)
+	if s.opt.failedBulkCount {
+		matched = 2
+	}
+	if result == nil || result.MatchedCount != matched {
+		t.Errorf("BulkWrite of updates got %+v, want %d matched", result, matched)
+	}
+}
+
+func (s *suite) testUpdateVersion(t *testing.T) {
+	s.seed(t, 1)
+
+	selector := db.Filter{"code": "code0"}
+	result, err := db.UpdateVersion(s.database, s.collection, selector, 0, db.Filter{"$set": db.Filter{"name": "first"}})
+	if err != nil {
+		t.Fatalf("UpdateVersion of a document without version: %v", err)
+	}
+	if result.ModifiedCount != 1 {
+		t.Errorf("UpdateVersion got %+v, want 1 modified", result)
+	}
+
+	_, err = db.UpdateVersion(s.database, s.collection, selector, 0, db.Filter{"$set": db.Filter{"name": "stale"}})
+	if errors.GetType(err) != errors.Conflict {
+		t.Errorf("UpdateVersion of a stale version got %v, want a Conflict error", err)
+	}
+
+	_, err = db.UpdateVersion(s.database, s.collection, selector, 1, db.Filter{"$set": db.Filter{"name": "second"}})
+	if err != nil {
+		t.Fatalf("UpdateVersion of the current version: %v", err)
+	}
+
+	var current struct {
+		Name    string `bson:"name"`
+		Version int64  `bson:"version"`
+	}
+	if err := s.database.FindOne(s.collection, selector, nil, &current); err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	if current.Name != "second" || current.Version != 2 {
+		t.Errorf("UpdateVersion got %+v, want name second and version 2", current)
+	}
+
+	_, err = db.UpdateVersion(s.database, s.collection, db.Filter{"code": "missing"}, 0, db.Filter{"$set": db.Filter{"name": "none"}})
+	if errors.GetType(err) != errors.NotFound {
+		t.Errorf("UpdateVersion of a missing document got %v, want a NotFound error", err)
+	}
+}
+
+func (s *suite) testApplyDB(t *testing.T) {
+	s.seed(t, 3)
+
+	var result brand
+	update := db.Filter{"$inc": db.Filter{"rank": 10}}
+	err := s.database.ApplyDB(s.collection, db.Filter{"code": "code1"}, update, &result)
+	if err != nil {
+		t.Fatalf("ApplyDB: %v", err)
+	}
+	if result.Rank != 11 {
+		t.Errorf("ApplyDB got rank %d, want the new rank 11", result.Rank)
+	}
+}
+
+func (s *suite) testIndex(t *testing.T) {
+	s.seed(t, 1)
+
+	index := db.IndexConfig{Key: []string{"code"}, Unique: true, Name: "mongotest_code"}
+	if !s.database.EnsureIndex(s.collection, index) {
+		t.Fatal("EnsureIndex failed")
+	}
+
+	indexes, err := s.database.ListIndexes(s.collection)
+	if err != nil {
+		t.Fatalf("ListIndexes: %v", err)
+	}
+	found := false
+	for _, i := range indexes {
+		if i.Name == index.Name {
+			found = true
+			if !i.Unique || len(i.Key) != 1 || i.Key[0] != "code" {
+				t.Errorf("ListIndexes got %+v, want unique index of code", i)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("ListIndexes got %+v, want index %s", indexes, index.Name)
+	}
+
+	_, err = s.database.InsertOne(s.collection, brand{Code: "code0"})
+	if err == nil {
+		t.Error("InsertOne of duplicate code got no error")
+	}
+
+	if !s.database.DropIndex(s.collection, index.Name) {
+		t.Fatal("DropIndex failed")
+	}
+
+	_, err = s.database.InsertOne(s.collection, brand{Code: "code0"})
+	if err != nil {
+		t.Errorf("InsertOne after DropIndex: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package mongotest
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	collation       bool
+	failedBulkCount bool
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithoutCollation expects the find methods to reject a collation with a
+// BadRequest error, as mgo does, instead of applying it
+func WithoutCollation() Option {
+	return optionFn(func(opt *option) {
+		opt.collation = false
+	})
+}
+
+// WithoutFailedBulkCounts expects BulkWrite to drop the matched and modified
+// counts of a group of updates with a failed operation, as mgo does
+func WithoutFailedBulkCounts() Option {
+	return optionFn(func(opt *option) {
+		opt.failedBulkCount = false
+	})
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{
+		collation:       true,
+		failedBulkCount: true,
+	}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+
+	return &opt
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ObjectID is a BSON ObjectId which is marshalled natively by both mgo and
+// mongo-driver, so models and filters do not depend on the type of a driver.
+type ObjectID [12]byte
+
+// NilObjectID is the zero value of ObjectID
+var NilObjectID ObjectID
+
+// NewObjectID generates a new ObjectID
+func NewObjectID() ObjectID {
+	return ObjectID(primitive.NewObjectID())
+}
+
+// ObjectIDFromHex parses the hex representation of an ObjectID
+func ObjectIDFromHex(s string) (ObjectID, error) {
+	var id ObjectID
+	if len(s) != 24 {
+		return id, fmt.Errorf("invalid object id %q", s)
+	}
+
+	_, err := hex.Decode(id[:], []byte(s))
+	if err != nil {
+		return id, fmt.Errorf("invalid object id %q", s)
+	}
+
+	return id, nil
+}
+
+// Hex returns the hex representation of the ObjectID
+func (id ObjectID) Hex() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id ObjectID) String() string {
+	return id.Hex()
+}
+
+// IsZero reports whether the ObjectID is the zero value, it is used by
+// mongo-driver for omitempty
+func (id ObjectID) IsZero() bool {
+	return id == NilObjectID
+}
+
+// MarshalJSON encodes the ObjectID as a hex string
+func (id ObjectID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(id.Hex())
+}
+
+// UnmarshalJSON decodes the ObjectID from a hex string
+func (id *ObjectID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*id = NilObjectID
+		return nil
+	}
+
+	parsed, err := ObjectIDFromHex(s)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// GetBSON implements bson.Getter of mgo
+func (id ObjectID) GetBSON() (interface{}, error) {
+	return bson.ObjectId(id[:]), nil
+}
+
+// SetBSON implements bson.Setter of mgo
+func (id *ObjectID) SetBSON(raw bson.Raw) error {
+	var oid bson.ObjectId
+	if err := raw.Unmarshal(&oid); err != nil {
+		return err
+	}
+	if !oid.Valid() {
+		return fmt.Errorf("invalid object id %q", string(oid))
+	}
+
+	copy(id[:], oid)
+	return nil
+}
+
+// MarshalBSONValue implements bson.ValueMarshaler of mongo-driver
+func (id ObjectID) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bsontype.ObjectID, id[:], nil
+}
+
+// UnmarshalBSONValue implements bson.ValueUnmarshaler of mongo-driver
+func (id *ObjectID) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t != bsontype.ObjectID || len(data) != 12 {
+		return fmt.Errorf("cannot decode %s into an object id", t)
+	}
+
+	copy(id[:], data)
+	return nil
+}
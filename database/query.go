@@ -0,0 +1,308 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// operators are the field operators accepted by Query.Op
+var operators = map[string]bool{
+	"$eq":        true,
+	"$ne":        true,
+	"$gt":        true,
+	"$gte":       true,
+	"$lt":        true,
+	"$lte":       true,
+	"$in":        true,
+	"$nin":       true,
+	"$exists":    true,
+	"$type":      true,
+	"$regex":     true,
+	"$options":   true,
+	"$mod":       true,
+	"$all":       true,
+	"$size":      true,
+	"$elemMatch": true,
+	"$not":       true,
+}
+
+// Query builds a filter with sort, projection, limit and skip, e.g.
+//
+//	Where("age").Gte(18).And("status").In("active", "pending").Sort("-created_at")
+//
+// Field names and operators are validated while building, the first error is
+// returned by Build.
+type Query struct {
+	fields     []string
+	conditions map[string]Filter
+	logical    map[string][][]Filter
+	field      string
+	sort       Sort
+	projection Filter
+	limit      int
+	skip       int
+	err        error
+}
+
+// QuerySpec is the result of a built query
+type QuerySpec struct {
+	Filter     Filter
+	Sort       Sort
+	Projection Filter
+	Limit      int
+	Skip       int
+}
+
+// FindOptions returns the sort, projection, limit and skip of the query as find
+// options
+func (s *QuerySpec) FindOptions() *FindOptions {
+	return &FindOptions{
+		Sort:       s.Sort,
+		Projection: s.Projection,
+		Limit:      s.Limit,
+		Skip:       s.Skip,
+	}
+}
+
+// NewQuery creates an empty query which matches all documents
+func NewQuery() *Query {
+	return &Query{
+		conditions: make(map[string]Filter),
+		logical:    make(map[string][][]Filter),
+	}
+}
+
+// Where creates a query with conditions on the field
+func Where(field string) *Query {
+	return NewQuery().Where(field)
+}
+
+// Where selects the field for the next conditions
+func (q *Query) Where(field string) *Query {
+	if q.err == nil && (field == "" || field != strings.TrimSpace(field) || strings.HasPrefix(field, "$")) {
+		q.err = fmt.Errorf("invalid field name %q", field)
+	}
+	q.field = field
+	return q
+}
+
+// And selects the field for the next conditions, it is an alias of Where
+func (q *Query) And(field string) *Query {
+	return q.Where(field)
+}
+
+// Eq matches values equal to value
+func (q *Query) Eq(value interface{}) *Query { return q.Op("$eq", value) }
+
+// Ne matches values not equal to value
+func (q *Query) Ne(value interface{}) *Query { return q.Op("$ne", value) }
+
+// Gt matches values greater than value
+func (q *Query) Gt(value interface{}) *Query { return q.Op("$gt", value) }
+
+// Gte matches values greater than or equal to value
+func (q *Query) Gte(value interface{}) *Query { return q.Op("$gte", value) }
+
+// Lt matches values less than value
+func (q *Query) Lt(value interface{}) *Query { return q.Op("$lt", value) }
+
+// Lte matches values less than or equal to value
+func (q *Query) Lte(value interface{}) *Query { return q.Op("$lte", value) }
+
+// In matches any of the values, a single slice is the list of values
+func (q *Query) In(values ...interface{}) *Query { return q.Op("$in", flatten(values)) }
+
+// Nin matches none of the values, a single slice is the list of values
+func (q *Query) Nin(values ...interface{}) *Query { return q.Op("$nin", flatten(values)) }
+
+// All matches arrays which contain all the values, a single slice is the list of
+// values
+func (q *Query) All(values ...interface{}) *Query { return q.Op("$all", flatten(values)) }
+
+// Exists matches documents which have the field if exists is true
+func (q *Query) Exists(exists bool) *Query { return q.Op("$exists", exists) }
+
+// Size matches arrays with the number of elements
+func (q *Query) Size(size int) *Query { return q.Op("$size", size) }
+
+// Regex matches strings with the pattern, options are the regex options, e.g. "i"
+func (q *Query) Regex(pattern string, options string) *Query {
+	q.Op("$regex", pattern)
+	if options != "" {
+		q.Op("$options", options)
+	}
+	return q
+}
+
+// ElemMatch matches arrays with at least one element matching the query
+func (q *Query) ElemMatch(query *Query) *Query {
+	filter, err := query.filter()
+	if err != nil {
+		q.setErr(err)
+		return q
+	}
+	return q.Op("$elemMatch", filter)
+}
+
+// Op adds a condition with the operator on the selected field, the operator must
+// be a known field operator.
+func (q *Query) Op(operator string, value interface{}) *Query {
+	if !operators[operator] {
+		q.setErr(fmt.Errorf("unknown operator %q", operator))
+		return q
+	}
+	if q.field == "" {
+		q.setErr(fmt.Errorf("operator %s has no field, call Where first", operator))
+		return q
+	}
+
+	if _, ok := q.conditions[q.field]; !ok {
+		q.fields = append(q.fields, q.field)
+		q.conditions[q.field] = Filter{}
+	}
+	q.conditions[q.field][operator] = value
+	return q
+}
+
+// Or matches documents matching any of the queries, the documents must match
+// every group of queries when it is called again
+func (q *Query) Or(queries ...*Query) *Query { return q.logicalOp("$or", queries) }
+
+// Nor matches documents matching none of the queries, the documents must match
+// every group of queries when it is called again
+func (q *Query) Nor(queries ...*Query) *Query { return q.logicalOp("$nor", queries) }
+
+// Sort sets the sort fields, a field name may be prefixed by - (minus) for it to
+// be sorted in reverse order
+func (q *Query) Sort(fields ...string) *Query {
+	q.sort = append(q.sort, fields...)
+	return q
+}
+
+// Select includes only the fields in the results
+func (q *Query) Select(fields ...string) *Query { return q.project(fields, 1) }
+
+// Exclude excludes the fields from the results
+func (q *Query) Exclude(fields ...string) *Query { return q.project(fields, 0) }
+
+// Limit sets the maximum number of results
+func (q *Query) Limit(limit int) *Query {
+	if limit < 0 {
+		q.setErr(fmt.Errorf("invalid limit %d", limit))
+	}
+	q.limit = limit
+	return q
+}
+
+// Skip sets the number of results to skip
+func (q *Query) Skip(skip int) *Query {
+	if skip < 0 {
+		q.setErr(fmt.Errorf("invalid skip %d", skip))
+	}
+	q.skip = skip
+	return q
+}
+
+// Build returns the filter and options of the query, or the first error made
+// while building.
+func (q *Query) Build() (*QuerySpec, error) {
+	filter, err := q.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuerySpec{
+		Filter:     filter,
+		Sort:       q.sort,
+		Projection: q.projection,
+		Limit:      q.limit,
+		Skip:       q.skip,
+	}, nil
+}
+
+func (q *Query) filter() (Filter, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	filter := Filter{}
+	for _, field := range q.fields {
+		conditions := q.conditions[field]
+		// A single equality on a scalar is written as field: value
+		if value, ok := conditions["$eq"]; ok && len(conditions) == 1 && !isDocument(value) {
+			filter[field] = value
+			continue
+		}
+		filter[field] = conditions
+	}
+	var and []Filter
+	for _, operator := range []string{"$or", "$nor"} {
+		groups := q.logical[operator]
+		if len(groups) == 1 {
+			filter[operator] = groups[0]
+			continue
+		}
+		for _, group := range groups {
+			and = append(and, Filter{operator: group})
+		}
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+
+	return filter, nil
+}
+
+func (q *Query) logicalOp(operator string, queries []*Query) *Query {
+	group := make([]Filter, 0, len(queries))
+	for _, query := range queries {
+		filter, err := query.filter()
+		if err != nil {
+			q.setErr(err)
+			return q
+		}
+		group = append(group, filter)
+	}
+	q.logical[operator] = append(q.logical[operator], group)
+	return q
+}
+
+func (q *Query) project(fields []string, include int) *Query {
+	if q.projection == nil {
+		q.projection = Filter{}
+	}
+	for _, field := range fields {
+		q.projection[field] = include
+	}
+	return q
+}
+
+func (q *Query) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// flatten returns the elements of a single slice value, so In(ids) matches the
+// ids and not an array of ids
+func flatten(values []interface{}) []interface{} {
+	if len(values) != 1 || values[0] == nil {
+		return values
+	}
+
+	v := reflect.ValueOf(values[0])
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return values
+	}
+
+	flat := make([]interface{}, v.Len())
+	for i := range flat {
+		flat[i] = v.Index(i).Interface()
+	}
+	return flat
+}
+
+func isDocument(value interface{}) bool {
+	return value != nil && reflect.TypeOf(value).Kind() == reflect.Map
+}
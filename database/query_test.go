@@ -0,0 +1,125 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryBuild(t *testing.T) {
+	ids := []string{"a", "b"}
+	tests := []struct {
+		name  string
+		query *Query
+		want  Filter
+	}{
+		{
+			name:  "empty",
+			query: NewQuery(),
+			want:  Filter{},
+		},
+		{
+			name:  "equality",
+			query: Where("code").Eq("dell"),
+			want:  Filter{"code": "dell"},
+		},
+		{
+			name:  "equality on a document",
+			query: Where("meta").Eq(Filter{"a": 1}),
+			want:  Filter{"meta": Filter{"$eq": Filter{"a": 1}}},
+		},
+		{
+			name:  "range",
+			query: Where("age").Gte(18).Lt(65).And("status").In("active", "pending"),
+			want: Filter{
+				"age":    Filter{"$gte": 18, "$lt": 65},
+				"status": Filter{"$in": []interface{}{"active", "pending"}},
+			},
+		},
+		{
+			name:  "slice values",
+			query: Where("_id").In(ids).And("tags").All([]string{"x"}).And("code").Nin([]interface{}{1, 2}),
+			want: Filter{
+				"_id":  Filter{"$in": []interface{}{"a", "b"}},
+				"tags": Filter{"$all": []interface{}{"x"}},
+				"code": Filter{"$nin": []interface{}{1, 2}},
+			},
+		},
+		{
+			name:  "bytes value",
+			query: Where("hash").In([]byte("ab")),
+			want:  Filter{"hash": Filter{"$in": []interface{}{[]byte("ab")}}},
+		},
+		{
+			name:  "regex",
+			query: Where("name").Regex("^de", "i"),
+			want:  Filter{"name": Filter{"$regex": "^de", "$options": "i"}},
+		},
+		{
+			name:  "elem match",
+			query: Where("items").ElemMatch(Where("qty").Gt(1)),
+			want:  Filter{"items": Filter{"$elemMatch": Filter{"qty": Filter{"$gt": 1}}}},
+		},
+		{
+			name:  "or",
+			query: NewQuery().Or(Where("a").Eq(1), Where("b").Eq(2)),
+			want:  Filter{"$or": []Filter{{"a": 1}, {"b": 2}}},
+		},
+		{
+			name:  "or twice",
+			query: NewQuery().Or(Where("a").Eq(1), Where("b").Eq(2)).Or(Where("c").Eq(3)).Nor(Where("d").Eq(4)),
+			want: Filter{
+				"$and": []Filter{
+					{"$or": []Filter{{"a": 1}, {"b": 2}}},
+					{"$or": []Filter{{"c": 3}}},
+				},
+				"$nor": []Filter{{"d": 4}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := tt.query.Build()
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if !reflect.DeepEqual(spec.Filter, tt.want) {
+				t.Errorf("Filter = %v, want %v", spec.Filter, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryBuildOptions(t *testing.T) {
+	spec, err := NewQuery().Sort("-created_at").Select("name").Exclude("_id").Limit(10).Skip(20).Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := &FindOptions{
+		Sort:       Sort{"-created_at"},
+		Projection: Filter{"name": 1, "_id": 0},
+		Limit:      10,
+		Skip:       20,
+	}
+	if got := spec.FindOptions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindOptions = %+v, want %+v", got, want)
+	}
+}
+
+func TestQueryBuildErrors(t *testing.T) {
+	tests := map[string]*Query{
+		"invalid field":    Where("$where").Eq(1),
+		"unknown operator": Where("a").Op("$where", 1),
+		"no field":         NewQuery().Eq(1),
+		"invalid limit":    NewQuery().Limit(-1),
+		"invalid skip":     NewQuery().Skip(-1),
+		"nested error":     NewQuery().Or(Where(" a").Eq(1)),
+	}
+
+	for name, query := range tests {
+		if _, err := query.Build(); err == nil {
+			t.Errorf("%s: Build succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+)
+
+// Model holds the id and timestamps which are managed by Repository, embed it
+// inline in the document:
+//
+//	type Brand struct {
+//		repository.Model `bson:",inline"`
+//		Code             string `json:"code" bson:"code"`
+//	}
+type Model struct {
+	ID        db.ObjectID `json:"id" bson:"_id"`
+	CreatedAt time.Time   `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at" bson:"updated_at"`
+	// DeletedAt is set by the soft deletes of softdelete.Mongo
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+}
+
+// BeforeCreate generates the id if it is not set and stamps the timestamps
+func (m *Model) BeforeCreate(now time.Time) {
+	if m.ID.IsZero() {
+		m.ID = db.NewObjectID()
+	}
+	m.CreatedAt = now
+	m.UpdatedAt = now
+}
+
+// BeforeUpdate stamps the updated time
+func (m *Model) BeforeUpdate(now time.Time) {
+	m.UpdatedAt = now
+}
+
+func (m *Model) model() *Model {
+	return m
+}
+
+// modeler is implemented by the documents embedding Model
+type modeler interface {
+	model() *Model
+}
+
+// Creator is implemented by documents which are prepared before inserted
+type Creator interface {
+	BeforeCreate(now time.Time)
+}
+
+// Updater is implemented by documents which are prepared before updated
+type Updater interface {
+	BeforeUpdate(now time.Time)
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/errors"
+	"github.com/quangdangfit/gosdk/utils/paging"
+)
+
+// Repository is a typed access to the documents of a collection, the documents
+// are identified by the hex of their ObjectID. If *T implements Creator or
+// Updater, e.g. by embedding Model, it is prepared before written.
+type Repository[T any] struct {
+	database   db.Mongo
+	collection string
+}
+
+func New[T any](database db.Mongo, collection string) *Repository[T] {
+	return &Repository[T]{
+		database:   database,
+		collection: collection,
+	}
+}
+
+// Get returns the document with the id.
+func (r *Repository[T]) Get(id string) (*T, error) {
+	filter, err := idFilter(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.FindOne(filter, nil)
+}
+
+// FindOne returns the first document matching the filter with the find options.
+func (r *Repository[T]) FindOne(filter db.Filter, opts *db.FindOptions) (*T, error) {
+	var result T
+	err := r.database.FindOne(r.collection, filter, opts, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Find returns all documents matching the filter with the find options.
+func (r *Repository[T]) Find(filter db.Filter, opts *db.FindOptions) ([]T, error) {
+	results := []T{}
+	err := r.database.FindMany(r.collection, filter, opts, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Paginate returns the documents of the page matching the filter with the find options.
+func (r *Repository[T]) Paginate(filter db.Filter, opts *db.FindOptions, page int, limit int, pagingOpts ...paging.Option) ([]T, *paging.Paging, error) {
+	results := []T{}
+	pageInfo, err := r.database.FindManyPaging(r.collection, filter, opts, page, limit, &results, pagingOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return results, pageInfo, nil
+}
+
+// Scroll returns the page of documents after the continuation token, see
+// database.Mongo FindManyCursor.
+func (r *Repository[T]) Scroll(filter db.Filter, sort db.Sort, cursor string, limit int) ([]T, *db.Cursor, error) {
+	results := []T{}
+	c, err := r.database.FindManyCursor(r.collection, filter, sort, cursor, limit, &results)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return results, c, nil
+}
+
+// Create inserts the document, the id and timestamps are set on the entity.
+func (r *Repository[T]) Create(entity *T) error {
+	if creator, ok := any(entity).(Creator); ok {
+		creator.BeforeCreate(time.Now().UTC())
+	}
+
+	_, err := r.database.InsertOne(r.collection, entity)
+	return err
+}
+
+// Update replaces the document with the id by entity, so entity should be the
+// full document, e.g. returned by Get: the fields missing in entity are removed.
+// The id and creation time of a Model are kept from the stored document. It
+// returns a NotFound error if no document has the id.
+func (r *Repository[T]) Update(id string, entity *T) error {
+	filter, err := idFilter(id)
+	if err != nil {
+		return err
+	}
+
+	if m, ok := any(entity).(modeler); ok {
+		var current Model
+		err = r.database.FindOne(r.collection, filter, &db.FindOptions{Projection: db.Filter{"created_at": 1}}, &current)
+		if errors.GetType(err) == errors.NotFound {
+			return errors.NotFound.Newf("%s %s not found", r.collection, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		model := m.model()
+		model.ID = filter["_id"].(db.ObjectID)
+		model.CreatedAt = current.CreatedAt
+	}
+	if updater, ok := any(entity).(Updater); ok {
+		updater.BeforeUpdate(time.Now().UTC())
+	}
+
+	replace := db.ReplaceOneModel{Filter: filter, Replacement: entity}
+	result, err := r.database.BulkWrite(r.collection, []db.WriteModel{replace}, true)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.NotFound.Newf("%s %s not found", r.collection, id)
+	}
+
+	return nil
+}
+
+// Delete removes the document with the id, it returns a NotFound error if no
+// document has the id.
+func (r *Repository[T]) Delete(id string) error {
+	filter, err := idFilter(id)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.database.DeleteOne(r.collection, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.NotFound.Newf("%s %s not found", r.collection, id)
+	}
+
+	return nil
+}
+
+func idFilter(id string) (db.Filter, error) {
+	oid, err := db.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.BadRequest.Wrap(err, "invalid id")
+	}
+
+	return db.Filter{"_id": oid}, nil
+}
@@ -0,0 +1,71 @@
+package repository_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/database/repository"
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+type brand struct {
+	repository.Model `bson:",inline"`
+	Code             string `bson:"code"`
+}
+
+type fakeMongo struct {
+	db.Mongo
+	createdAt time.Time
+	found     bool
+	ops       []db.WriteModel
+}
+
+func (f *fakeMongo) FindOne(table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	if !f.found {
+		return errors.NotFound.New("not found")
+	}
+
+	result.(*repository.Model).CreatedAt = f.createdAt
+	return nil
+}
+
+func (f *fakeMongo) BulkWrite(table string, ops []db.WriteModel, ordered bool) (*db.BulkWriteResult, error) {
+	f.ops = append(f.ops, ops...)
+	return &db.BulkWriteResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func TestUpdate(t *testing.T) {
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	database := &fakeMongo{createdAt: createdAt, found: true}
+	brands := repository.New[brand](database, "brand")
+
+	id := db.NewObjectID()
+	entity := &brand{Code: "gosdk"}
+	if err := brands.Update(id.Hex(), entity); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if entity.ID != id || !entity.CreatedAt.Equal(createdAt) || entity.UpdatedAt.IsZero() {
+		t.Errorf("Update set id %s, created at %s, updated at %s, want the stored id and creation time", entity.ID.Hex(), entity.CreatedAt, entity.UpdatedAt)
+	}
+
+	want := []db.WriteModel{db.ReplaceOneModel{Filter: db.Filter{"_id": id}, Replacement: entity}}
+	if !reflect.DeepEqual(database.ops, want) {
+		t.Errorf("Update wrote %v, want %v", database.ops, want)
+	}
+}
+
+func TestUpdateNotFound(t *testing.T) {
+	database := &fakeMongo{}
+	brands := repository.New[brand](database, "brand")
+
+	err := brands.Update(db.NewObjectID().Hex(), &brand{Code: "gosdk"})
+	if errors.GetType(err) != errors.NotFound {
+		t.Fatalf("Update = %v, want a NotFound error", err)
+	}
+	if len(database.ops) != 0 {
+		t.Errorf("Update wrote %v, want nothing", database.ops)
+	}
+}
@@ -0,0 +1,27 @@
+package database
+
+// InsertOneResult is the result of InsertOne, InsertedID is the _id of the
+// document, which is generated as an ObjectID if the document has none
+type InsertOneResult struct {
+	InsertedID interface{}
+}
+
+// InsertManyResult is the result of InsertMany, the ids are in the order of the
+// documents
+type InsertManyResult struct {
+	InsertedIDs []interface{}
+}
+
+// UpdateResult is the result of an update or upsert, UpsertedID is the _id of
+// the inserted document if UpsertedCount is 1
+type UpdateResult struct {
+	MatchedCount  int64
+	ModifiedCount int64
+	UpsertedCount int64
+	UpsertedID    interface{}
+}
+
+// DeleteResult is the result of a delete
+type DeleteResult struct {
+	DeletedCount int64
+}
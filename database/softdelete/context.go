@@ -0,0 +1,92 @@
+package softdelete
+
+import (
+	"context"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/utils/paging"
+)
+
+// softDeleteContext is the soft delete of a database.MongoContext, the context
+// operations run the soft delete operations on a view of the database bound to
+// their ctx
+type softDeleteContext struct {
+	*softDelete
+	ctxDatabase db.MongoContext
+}
+
+var _ db.MongoContext = (*softDeleteContext)(nil)
+
+func (s *softDeleteContext) view(ctx context.Context) *softDelete {
+	return &softDelete{database: db.WithContext(s.ctxDatabase, ctx), opt: s.opt, scope: s.scope}
+}
+
+func (s *softDeleteContext) FindOneContext(ctx context.Context, table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	return s.view(ctx).FindOne(table, query, opts, result)
+}
+
+func (s *softDeleteContext) FindManyContext(ctx context.Context, table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	return s.view(ctx).FindMany(table, query, opts, result)
+}
+
+func (s *softDeleteContext) FindManyPagingContext(ctx context.Context, table string, query db.Filter, opts *db.FindOptions, offset int, limit int, result interface{}, pagingOpts ...paging.Option) (*paging.Paging, error) {
+	return s.view(ctx).FindManyPaging(table, query, opts, offset, limit, result, pagingOpts...)
+}
+
+func (s *softDeleteContext) FindManyCursorContext(ctx context.Context, table string, query db.Filter, sort db.Sort, cursor string, limit int, result interface{}) (*db.Cursor, error) {
+	return s.view(ctx).FindManyCursor(table, query, sort, cursor, limit, result)
+}
+
+func (s *softDeleteContext) PipeAllContext(ctx context.Context, table string, pipeline interface{}, result interface{}) error {
+	return s.view(ctx).PipeAll(table, pipeline, result)
+}
+
+func (s *softDeleteContext) InsertOneContext(ctx context.Context, table string, payload interface{}) (*db.InsertOneResult, error) {
+	return s.view(ctx).InsertOne(table, payload)
+}
+
+func (s *softDeleteContext) InsertManyContext(ctx context.Context, table string, payload []interface{}) (*db.InsertManyResult, error) {
+	return s.view(ctx).InsertMany(table, payload)
+}
+
+func (s *softDeleteContext) UpsertContext(ctx context.Context, table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return s.view(ctx).Upsert(table, selector, payload)
+}
+
+func (s *softDeleteContext) UpdateOneContext(ctx context.Context, table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return s.view(ctx).UpdateOne(table, selector, payload)
+}
+
+func (s *softDeleteContext) UpdateManyContext(ctx context.Context, table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return s.view(ctx).UpdateMany(table, selector, payload)
+}
+
+func (s *softDeleteContext) DeleteOneContext(ctx context.Context, table string, selector db.Filter) (*db.DeleteResult, error) {
+	return s.view(ctx).DeleteOne(table, selector)
+}
+
+func (s *softDeleteContext) DeleteManyContext(ctx context.Context, table string, selector db.Filter) (*db.DeleteResult, error) {
+	return s.view(ctx).DeleteMany(table, selector)
+}
+
+func (s *softDeleteContext) BulkWriteContext(ctx context.Context, table string, ops []db.WriteModel, ordered bool) (*db.BulkWriteResult, error) {
+	return s.view(ctx).BulkWrite(table, ops, ordered)
+}
+
+func (s *softDeleteContext) ApplyDBContext(ctx context.Context, table string, selector db.Filter, payload interface{}, result interface{}) error {
+	return s.view(ctx).ApplyDB(table, selector, payload, result)
+}
+
+// WithTransaction runs fn in a transaction of the database, the operations of
+// tx are soft deleted as the ones of the view
+func (s *softDeleteContext) WithTransaction(ctx context.Context, fn func(tx db.Mongo) error) error {
+	return s.ctxDatabase.WithTransaction(ctx, func(tx db.Mongo) error {
+		return fn(wrap(tx, s.opt, s.scope))
+	})
+}
+
+// Watch reports the changes of all documents, a soft delete is an update of
+// the deletion field
+func (s *softDeleteContext) Watch(ctx context.Context, table string, pipeline interface{}, handler db.ChangeHandler, opts *db.WatchOptions) error {
+	return s.ctxDatabase.Watch(ctx, table, pipeline, handler, opts)
+}
@@ -0,0 +1,46 @@
+package softdelete
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	field       string
+	collections map[string]bool
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithField sets the field of the deletion time, default is deleted_at
+func WithField(field string) Option {
+	return optionFn(func(opt *option) {
+		opt.field = field
+	})
+}
+
+// WithCollections only soft deletes the documents of the collections, the other
+// collections are deleted as usual. Default is all collections.
+func WithCollections(collections ...string) Option {
+	return optionFn(func(opt *option) {
+		for _, collection := range collections {
+			opt.collections[collection] = true
+		}
+	})
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{
+		field:       DefaultField,
+		collections: map[string]bool{},
+	}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+
+	return &opt
+}
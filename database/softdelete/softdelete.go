@@ -0,0 +1,351 @@
+// Package softdelete wraps a database.Mongo so the documents are never removed
+// by DeleteOne and DeleteMany, the deletion time is set in the deleted_at field
+// instead. The find, update and aggregate operations exclude the soft deleted
+// documents, WithDeleted and OnlyDeleted return views which include them. If the
+// wrapped database implements database.MongoContext, so does the wrapper.
+package softdelete
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/errors"
+	"github.com/quangdangfit/gosdk/utils/paging"
+)
+
+// DefaultField is the default field of the deletion time
+const DefaultField = "deleted_at"
+
+// Mongo is a database.Mongo which soft deletes the documents
+type Mongo interface {
+	db.Mongo
+
+	// WithDeleted returns a view of the database whose operations include the
+	// soft deleted documents
+	WithDeleted() Mongo
+	// OnlyDeleted returns a view of the database whose operations only match
+	// the soft deleted documents
+	OnlyDeleted() Mongo
+	// Restore clears the deletion of the soft deleted documents matching the
+	// selector
+	Restore(table string, selector db.Filter) (*db.UpdateResult, error)
+	// Purge removes the soft deleted documents matching the selector from the
+	// database, the documents which are not soft deleted are kept
+	Purge(table string, selector db.Filter) (*db.DeleteResult, error)
+}
+
+type scope int
+
+const (
+	excludeDeleted scope = iota
+	includeDeleted
+	onlyDeleted
+)
+
+type softDelete struct {
+	database db.Mongo
+	opt      *option
+	scope    scope
+}
+
+var _ Mongo = (*softDelete)(nil)
+
+// New wraps the database so the documents are soft deleted. The documents are
+// soft deleted once, deleting a soft deleted document again does not match it.
+// The $match stage of PipeAll is prepended to the pipeline, so pipelines which
+// must start by another stage, e.g. $geoNear, should run on WithDeleted.
+//
+// If the database implements database.MongoContext, the returned Mongo
+// implements it too and the operations of WithTransaction are soft deleted.
+func New(database db.Mongo, opts ...Option) Mongo {
+	return wrap(database, getOption(opts...), excludeDeleted)
+}
+
+// wrap returns the view of the database with the scope, which implements
+// database.MongoContext if the database does
+func wrap(database db.Mongo, opt *option, scope scope) Mongo {
+	s := &softDelete{database: database, opt: opt, scope: scope}
+	if c, ok := database.(db.MongoContext); ok {
+		return &softDeleteContext{softDelete: s, ctxDatabase: c}
+	}
+
+	return s
+}
+
+func (s *softDelete) WithDeleted() Mongo {
+	return wrap(s.database, s.opt, includeDeleted)
+}
+
+func (s *softDelete) OnlyDeleted() Mongo {
+	return wrap(s.database, s.opt, onlyDeleted)
+}
+
+// enabled reports whether the documents of the collection are soft deleted
+func (s *softDelete) enabled(table string) bool {
+	return len(s.opt.collections) == 0 || s.opt.collections[table]
+}
+
+// notDeleted matches the documents whose field is missing or null
+func (s *softDelete) notDeleted() db.Filter {
+	return db.Filter{s.opt.field: nil}
+}
+
+// notDeletedUpsert matches the same documents as notDeleted, it is not an
+// equality so an upsert does not copy the field to the inserted document
+func (s *softDelete) notDeletedUpsert() db.Filter {
+	return db.Filter{s.opt.field: db.Filter{"$not": db.Filter{"$ne": nil}}}
+}
+
+func (s *softDelete) deleted() db.Filter {
+	return db.Filter{s.opt.field: db.Filter{"$ne": nil}}
+}
+
+func (s *softDelete) deletion() db.Filter {
+	return db.Filter{"$set": db.Filter{s.opt.field: time.Now().UTC()}}
+}
+
+// upsertScoped restricts the selector of an upsert to the documents of the view
+func (s *softDelete) upsertScoped(table string, selector db.Filter) db.Filter {
+	if s.enabled(table) && s.scope == excludeDeleted {
+		return and(selector, s.notDeletedUpsert())
+	}
+
+	return s.scoped(table, selector)
+}
+
+// scoped restricts the query to the documents of the view
+func (s *softDelete) scoped(table string, query db.Filter) db.Filter {
+	if !s.enabled(table) {
+		return query
+	}
+
+	switch s.scope {
+	case includeDeleted:
+		return query
+	case onlyDeleted:
+		return and(query, s.deleted())
+	default:
+		return and(query, s.notDeleted())
+	}
+}
+
+func and(query db.Filter, clause db.Filter) db.Filter {
+	if len(query) == 0 {
+		return clause
+	}
+
+	return db.Filter{"$and": []db.Filter{query, clause}}
+}
+
+func (s *softDelete) EnsureIndex(collectionName string, index db.IndexConfig) bool {
+	return s.database.EnsureIndex(collectionName, index)
+}
+
+func (s *softDelete) DropIndex(collectionName string, name string) bool {
+	return s.database.DropIndex(collectionName, name)
+}
+
+func (s *softDelete) ListIndexes(collectionName string) ([]db.IndexConfig, error) {
+	return s.database.ListIndexes(collectionName)
+}
+
+func (s *softDelete) DropCollection(collectionName string) error {
+	return s.database.DropCollection(collectionName)
+}
+
+func (s *softDelete) FindOne(table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	return s.database.FindOne(table, s.scoped(table, query), opts, result)
+}
+
+func (s *softDelete) FindMany(table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	return s.database.FindMany(table, s.scoped(table, query), opts, result)
+}
+
+func (s *softDelete) FindManyPaging(table string, query db.Filter, opts *db.FindOptions, offset int, limit int, result interface{}, pagingOpts ...paging.Option) (*paging.Paging, error) {
+	return s.database.FindManyPaging(table, s.scoped(table, query), opts, offset, limit, result, pagingOpts...)
+}
+
+func (s *softDelete) FindManyCursor(table string, query db.Filter, sort db.Sort, cursor string, limit int, result interface{}) (*db.Cursor, error) {
+	return s.database.FindManyCursor(table, s.scoped(table, query), sort, cursor, limit, result)
+}
+
+// PipeAll runs the pipeline on the documents of the view, the pipeline must be
+// a slice of stages
+func (s *softDelete) PipeAll(table string, pipeline interface{}, result interface{}) error {
+	match := s.scoped(table, nil)
+	if len(match) == 0 {
+		return s.database.PipeAll(table, pipeline, result)
+	}
+
+	stages, err := prepend(db.Filter{"$match": match}, pipeline)
+	if err != nil {
+		return err
+	}
+
+	return s.database.PipeAll(table, stages, result)
+}
+
+// prepend returns the stages of the pipeline after the stage
+func prepend(stage db.Filter, pipeline interface{}) ([]interface{}, error) {
+	if pipeline == nil {
+		return []interface{}{stage}, nil
+	}
+
+	v := reflect.ValueOf(pipeline)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("pipeline must be a slice of stages, got %T", pipeline)
+	}
+
+	stages := make([]interface{}, 0, v.Len()+1)
+	stages = append(stages, stage)
+	for i := 0; i < v.Len(); i++ {
+		stages = append(stages, v.Index(i).Interface())
+	}
+
+	return stages, nil
+}
+
+func (s *softDelete) Iterate(ctx context.Context, table string, query db.Filter, opts *db.IterOptions, fn func(decode func(v interface{}) error) error) error {
+	return s.database.Iterate(ctx, table, s.scoped(table, query), opts, fn)
+}
+
+func (s *softDelete) InsertOne(table string, payload interface{}) (*db.InsertOneResult, error) {
+	return s.database.InsertOne(table, payload)
+}
+
+func (s *softDelete) InsertMany(table string, payload []interface{}) (*db.InsertManyResult, error) {
+	return s.database.InsertMany(table, payload)
+}
+
+// Upsert updates the document of the view matching the selector, or inserts it.
+// It returns an errors.Conflict error if the selector matches a soft deleted
+// document, which must be restored before being upserted.
+func (s *softDelete) Upsert(table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	if s.enabled(table) && s.scope == excludeDeleted {
+		err := s.checkNotDeleted(table, selector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.database.Upsert(table, s.upsertScoped(table, selector), payload)
+}
+
+// checkNotDeleted returns an errors.Conflict error if the selector matches a
+// soft deleted document
+func (s *softDelete) checkNotDeleted(table string, selector db.Filter) error {
+	var current db.Filter
+	err := s.database.FindOne(table, and(selector, s.deleted()), &db.FindOptions{Projection: db.Filter{"_id": 1}}, &current)
+	if err == nil {
+		return errors.Conflict.Newf("%s: the document matching the selector is soft deleted, restore it before upserting", table)
+	}
+	if errors.GetType(err) == errors.NotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (s *softDelete) UpdateOne(table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return s.database.UpdateOne(table, s.scoped(table, selector), payload)
+}
+
+func (s *softDelete) UpdateMany(table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	return s.database.UpdateMany(table, s.scoped(table, selector), payload)
+}
+
+// DeleteOne sets the deletion time of the first document matching the selector
+// which is not soft deleted
+func (s *softDelete) DeleteOne(table string, selector db.Filter) (*db.DeleteResult, error) {
+	if !s.enabled(table) {
+		return s.database.DeleteOne(table, selector)
+	}
+
+	result, err := s.database.UpdateOne(table, and(selector, s.notDeleted()), s.deletion())
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.DeleteResult{DeletedCount: result.MatchedCount}, nil
+}
+
+// DeleteMany sets the deletion time of the documents matching the selector
+// which are not soft deleted
+func (s *softDelete) DeleteMany(table string, selector db.Filter) (*db.DeleteResult, error) {
+	if !s.enabled(table) {
+		return s.database.DeleteMany(table, selector)
+	}
+
+	result, err := s.database.UpdateMany(table, and(selector, s.notDeleted()), s.deletion())
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.DeleteResult{DeletedCount: result.MatchedCount}, nil
+}
+
+// BulkWrite runs the operations on the documents of the view, the deletes are
+// soft deletes which are counted as matched and modified documents. The upserts
+// are not checked against the soft deleted documents, the upsert of a soft
+// deleted _id fails with a duplicate key error.
+func (s *softDelete) BulkWrite(table string, ops []db.WriteModel, ordered bool) (*db.BulkWriteResult, error) {
+	if !s.enabled(table) {
+		return s.database.BulkWrite(table, ops, ordered)
+	}
+
+	models := make([]db.WriteModel, len(ops))
+	for i, op := range ops {
+		switch op := op.(type) {
+		case db.UpdateOneModel:
+			op.Filter = s.bulkScoped(table, op.Filter, op.Upsert)
+			models[i] = op
+		case db.UpdateManyModel:
+			op.Filter = s.bulkScoped(table, op.Filter, op.Upsert)
+			models[i] = op
+		case db.ReplaceOneModel:
+			op.Filter = s.bulkScoped(table, op.Filter, op.Upsert)
+			models[i] = op
+		case db.DeleteOneModel:
+			models[i] = db.UpdateOneModel{Filter: and(op.Filter, s.notDeleted()), Update: s.deletion()}
+		case db.DeleteManyModel:
+			models[i] = db.UpdateManyModel{Filter: and(op.Filter, s.notDeleted()), Update: s.deletion()}
+		default:
+			models[i] = op
+		}
+	}
+
+	return s.database.BulkWrite(table, models, ordered)
+}
+
+func (s *softDelete) bulkScoped(table string, filter db.Filter, upsert bool) db.Filter {
+	if upsert {
+		return s.upsertScoped(table, filter)
+	}
+
+	return s.scoped(table, filter)
+}
+
+// ApplyDB updates the document of the view matching the selector, a soft deleted
+// document is not matched so an errors.NotFound error is returned.
+func (s *softDelete) ApplyDB(table string, selector db.Filter, payload interface{}, result interface{}) error {
+	return s.database.ApplyDB(table, s.scoped(table, selector), payload, result)
+}
+
+func (s *softDelete) Restore(table string, selector db.Filter) (*db.UpdateResult, error) {
+	return s.database.UpdateMany(table, and(selector, s.deleted()), db.Filter{"$unset": db.Filter{s.opt.field: ""}})
+}
+
+func (s *softDelete) Purge(table string, selector db.Filter) (*db.DeleteResult, error) {
+	return s.database.DeleteMany(table, and(selector, s.deleted()))
+}
+
+func (s *softDelete) Ping(ctx context.Context) error {
+	return s.database.Ping(ctx)
+}
+
+func (s *softDelete) Close(ctx context.Context) error {
+	return s.database.Close(ctx)
+}
@@ -0,0 +1,290 @@
+package softdelete
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	db "github.com/quangdangfit/gosdk/database"
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+type call struct {
+	op      string
+	filter  db.Filter
+	payload interface{}
+	ctx     context.Context
+}
+
+// fakeMongo records the operations, it implements database.MongoContext, wrap
+// it in plainMongo for a database.Mongo only
+type fakeMongo struct {
+	db.MongoContext
+	calls   []call
+	findErr error
+	matched int64
+}
+
+type plainMongo struct {
+	db.Mongo
+}
+
+func (f *fakeMongo) record(op string, filter db.Filter, payload interface{}) {
+	f.calls = append(f.calls, call{op: op, filter: filter, payload: payload})
+}
+
+func (f *fakeMongo) FindOne(_ string, query db.Filter, _ *db.FindOptions, _ interface{}) error {
+	f.record("FindOne", query, nil)
+	return f.findErr
+}
+
+func (f *fakeMongo) FindOneContext(ctx context.Context, table string, query db.Filter, opts *db.FindOptions, result interface{}) error {
+	err := f.FindOne(table, query, opts, result)
+	f.calls[len(f.calls)-1].ctx = ctx
+	return err
+}
+
+func (f *fakeMongo) FindMany(_ string, query db.Filter, _ *db.FindOptions, _ interface{}) error {
+	f.record("FindMany", query, nil)
+	return nil
+}
+
+func (f *fakeMongo) PipeAll(_ string, pipeline interface{}, _ interface{}) error {
+	f.record("PipeAll", nil, pipeline)
+	return nil
+}
+
+func (f *fakeMongo) Upsert(_ string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	f.record("Upsert", selector, payload)
+	return &db.UpdateResult{}, nil
+}
+
+func (f *fakeMongo) UpdateOne(_ string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	f.record("UpdateOne", selector, payload)
+	return &db.UpdateResult{MatchedCount: f.matched, ModifiedCount: f.matched}, nil
+}
+
+func (f *fakeMongo) UpdateOneContext(ctx context.Context, table string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	result, err := f.UpdateOne(table, selector, payload)
+	f.calls[len(f.calls)-1].ctx = ctx
+	return result, err
+}
+
+func (f *fakeMongo) UpdateMany(_ string, selector db.Filter, payload interface{}) (*db.UpdateResult, error) {
+	f.record("UpdateMany", selector, payload)
+	return &db.UpdateResult{MatchedCount: f.matched, ModifiedCount: f.matched}, nil
+}
+
+func (f *fakeMongo) DeleteOne(_ string, selector db.Filter) (*db.DeleteResult, error) {
+	f.record("DeleteOne", selector, nil)
+	return &db.DeleteResult{DeletedCount: f.matched}, nil
+}
+
+func (f *fakeMongo) DeleteMany(_ string, selector db.Filter) (*db.DeleteResult, error) {
+	f.record("DeleteMany", selector, nil)
+	return &db.DeleteResult{DeletedCount: f.matched}, nil
+}
+
+func (f *fakeMongo) BulkWrite(_ string, ops []db.WriteModel, _ bool) (*db.BulkWriteResult, error) {
+	f.record("BulkWrite", nil, ops)
+	return &db.BulkWriteResult{}, nil
+}
+
+func (f *fakeMongo) WithTransaction(_ context.Context, fn func(tx db.Mongo) error) error {
+	return fn(f)
+}
+
+var (
+	query      = db.Filter{"code": "dell"}
+	notDeleted = db.Filter{"$and": []db.Filter{query, {DefaultField: nil}}}
+	deleted    = db.Filter{"$and": []db.Filter{query, {DefaultField: db.Filter{"$ne": nil}}}}
+)
+
+func TestScoped(t *testing.T) {
+	tests := []struct {
+		name  string
+		view  func(database db.Mongo) db.Mongo
+		query db.Filter
+		want  db.Filter
+	}{
+		{"default", func(d db.Mongo) db.Mongo { return New(d) }, query, notDeleted},
+		{"empty query", func(d db.Mongo) db.Mongo { return New(d) }, nil, db.Filter{DefaultField: nil}},
+		{"with deleted", func(d db.Mongo) db.Mongo { return New(d).WithDeleted() }, query, query},
+		{"only deleted", func(d db.Mongo) db.Mongo { return New(d).OnlyDeleted() }, query, deleted},
+		{"other collection", func(d db.Mongo) db.Mongo { return New(d, WithCollections("customer")) }, query, query},
+		{"field", func(d db.Mongo) db.Mongo { return New(d, WithField("removed_at")) }, query,
+			db.Filter{"$and": []db.Filter{query, {"removed_at": nil}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := &fakeMongo{}
+			tt.view(database).FindMany("brand", tt.query, nil, nil)
+			tt.view(database).UpdateOne("brand", tt.query, db.Filter{"$set": db.Filter{"name": "Dell"}})
+
+			for _, c := range database.calls {
+				if !reflect.DeepEqual(c.filter, tt.want) {
+					t.Errorf("%s filter = %v, want %v", c.op, c.filter, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		delete func(s Mongo) (*db.DeleteResult, error)
+		op     string
+	}{
+		{"one", func(s Mongo) (*db.DeleteResult, error) { return s.DeleteOne("brand", query) }, "UpdateOne"},
+		{"many", func(s Mongo) (*db.DeleteResult, error) { return s.DeleteMany("brand", query) }, "UpdateMany"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := &fakeMongo{matched: 2}
+			result, err := tt.delete(New(database))
+			if err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if result.DeletedCount != 2 {
+				t.Errorf("DeletedCount = %d, want the matched count 2", result.DeletedCount)
+			}
+
+			c := database.calls[0]
+			if c.op != tt.op || !reflect.DeepEqual(c.filter, notDeleted) {
+				t.Fatalf("call = %s %v, want %s %v", c.op, c.filter, tt.op, notDeleted)
+			}
+			set, _ := c.payload.(db.Filter)["$set"].(db.Filter)
+			if at, ok := set[DefaultField].(time.Time); !ok || time.Since(at) > time.Minute {
+				t.Errorf("payload = %v, want the deletion time in $set", c.payload)
+			}
+		})
+	}
+
+	database := &fakeMongo{}
+	New(database, WithCollections("customer")).DeleteOne("brand", query)
+	if c := database.calls[0]; c.op != "DeleteOne" || !reflect.DeepEqual(c.filter, query) {
+		t.Errorf("delete of another collection = %s %v, want a DeleteOne", c.op, c.filter)
+	}
+}
+
+func TestRestoreAndPurge(t *testing.T) {
+	database := &fakeMongo{}
+	s := New(database)
+	s.Restore("brand", query)
+	s.Purge("brand", query)
+
+	want := []call{
+		{op: "UpdateMany", filter: deleted, payload: db.Filter{"$unset": db.Filter{DefaultField: ""}}},
+		{op: "DeleteMany", filter: deleted},
+	}
+	if !reflect.DeepEqual(database.calls, want) {
+		t.Errorf("calls = %+v, want %+v", database.calls, want)
+	}
+}
+
+func TestPipeAll(t *testing.T) {
+	group := db.Filter{"$group": db.Filter{"_id": "$brand"}}
+
+	database := &fakeMongo{}
+	if err := New(database).PipeAll("brand", []db.Filter{group}, nil); err != nil {
+		t.Fatalf("PipeAll: %v", err)
+	}
+	want := []interface{}{db.Filter{"$match": db.Filter{DefaultField: nil}}, group}
+	if got := database.calls[0].payload; !reflect.DeepEqual(got, want) {
+		t.Errorf("pipeline = %v, want %v", got, want)
+	}
+
+	database = &fakeMongo{}
+	New(database).WithDeleted().PipeAll("brand", []db.Filter{group}, nil)
+	if got := database.calls[0].payload; !reflect.DeepEqual(got, []db.Filter{group}) {
+		t.Errorf("pipeline of WithDeleted = %v, want it unchanged", got)
+	}
+
+	if err := New(&fakeMongo{}).PipeAll("brand", group, nil); err == nil {
+		t.Errorf("PipeAll of a stage succeeded, want an error")
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	payload := db.Filter{"$set": db.Filter{"name": "Dell"}}
+
+	database := &fakeMongo{}
+	_, err := New(database).Upsert("brand", query, payload)
+	if errors.GetType(err) != errors.Conflict {
+		t.Fatalf("Upsert of a soft deleted document = %v, want a Conflict error", err)
+	}
+	if len(database.calls) != 1 || !reflect.DeepEqual(database.calls[0].filter, deleted) {
+		t.Errorf("calls = %+v, want only the lookup of the soft deleted document", database.calls)
+	}
+
+	database = &fakeMongo{findErr: errors.NotFound.New("not found")}
+	if _, err := New(database).Upsert("brand", query, payload); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	// The selector must not have an equality on the field, it would be copied
+	// to the inserted document
+	want := db.Filter{"$and": []db.Filter{query, {DefaultField: db.Filter{"$not": db.Filter{"$ne": nil}}}}}
+	if c := database.calls[1]; c.op != "Upsert" || !reflect.DeepEqual(c.filter, want) {
+		t.Errorf("call = %s %v, want Upsert %v", c.op, c.filter, want)
+	}
+}
+
+func TestBulkWrite(t *testing.T) {
+	database := &fakeMongo{}
+	New(database).BulkWrite("brand", []db.WriteModel{
+		db.InsertOneModel{Document: query},
+		db.UpdateOneModel{Filter: query, Update: db.Filter{}, Upsert: true},
+		db.DeleteManyModel{Filter: query},
+	}, true)
+
+	ops := database.calls[0].payload.([]db.WriteModel)
+	if _, ok := ops[0].(db.InsertOneModel); !ok {
+		t.Errorf("operation 0 = %T, want the insert", ops[0])
+	}
+	if op, ok := ops[1].(db.UpdateOneModel); !ok || op.Filter["$and"].([]db.Filter)[1][DefaultField] == nil {
+		t.Errorf("operation 1 = %+v, want the upsert without equality on the field", ops[1])
+	}
+	if op, ok := ops[2].(db.UpdateManyModel); !ok || !reflect.DeepEqual(op.Filter, notDeleted) {
+		t.Errorf("operation 2 = %+v, want a soft delete", ops[2])
+	}
+}
+
+type ctxKey struct{}
+
+func TestContext(t *testing.T) {
+	if _, ok := New(plainMongo{&fakeMongo{}}).(db.MongoContext); ok {
+		t.Errorf("wrapper of a database.Mongo implements database.MongoContext")
+	}
+
+	database := &fakeMongo{}
+	s, ok := New(database).(db.MongoContext)
+	if !ok {
+		t.Fatal("wrapper of a database.MongoContext does not implement it")
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	s.FindOneContext(ctx, "brand", query, nil, nil)
+	if c := database.calls[0]; c.ctx != ctx || !reflect.DeepEqual(c.filter, notDeleted) {
+		t.Errorf("FindOneContext = %v with %v, want the scoped query with ctx", c.filter, c.ctx)
+	}
+
+	s.DeleteOneContext(ctx, "brand", query)
+	if c := database.calls[1]; c.op != "UpdateOne" || c.ctx != ctx {
+		t.Errorf("DeleteOneContext = %s with %v, want a soft delete with ctx", c.op, c.ctx)
+	}
+
+	err := s.WithTransaction(ctx, func(tx db.Mongo) error {
+		_, err := tx.DeleteOne("brand", query)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	if c := database.calls[2]; c.op != "UpdateOne" {
+		t.Errorf("DeleteOne of the transaction = %s, want a soft delete", c.op)
+	}
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+// VersionField is the field of the version of the documents updated by
+// UpdateVersion
+const VersionField = "version"
+
+// UpdateVersion updates the document matching the selector only if its version
+// is version, the version is incremented by the same update so concurrent
+// updates of a version do not overwrite each other. A document without version
+// field has version 0. The update must be made of operators with Filter fields,
+// e.g. Filter{"$set": Filter{...}}, and must not change the version, otherwise
+// an errors.BadRequest error is returned.
+//
+// It returns an errors.Conflict error if the version of the document changed,
+// and an errors.NotFound error if no document matches the selector.
+func UpdateVersion(database Mongo, table string, selector Filter, version int64, update Filter) (*UpdateResult, error) {
+	versioned, err := incVersion(update)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := database.UpdateOne(table, withVersion(selector, version), versioned)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount > 0 {
+		return result, nil
+	}
+
+	// Nothing matched, the document is missing or its version changed
+	var current Filter
+	err = database.FindOne(table, selector, &FindOptions{Projection: Filter{VersionField: 1}}, &current)
+	if err != nil {
+		return nil, err
+	}
+
+	err = errors.Conflict.Newf("%s was modified, version %d is not the current version %v", table, version, current[VersionField])
+	return nil, errors.AddErrorContext(err, table, "UpdateVersion")
+}
+
+// withVersion restricts the selector to the version, the version 0 matches the
+// documents without version field
+func withVersion(selector Filter, version int64) Filter {
+	clause := Filter{VersionField: version}
+	if version == 0 {
+		clause = Filter{VersionField: Filter{"$in": []interface{}{0, nil}}}
+	}
+	if len(selector) == 0 {
+		return clause
+	}
+
+	return Filter{"$and": []Filter{selector, clause}}
+}
+
+// incVersion returns a copy of the update which also increments the version
+func incVersion(update Filter) (Filter, error) {
+	versioned := make(Filter, len(update)+1)
+	for operator, fields := range update {
+		if !strings.HasPrefix(operator, "$") {
+			return nil, errors.BadRequest.Newf("update must be made of operators, got %s", operator)
+		}
+
+		current, ok := filterOf(fields)
+		if !ok {
+			return nil, errors.BadRequest.Newf("%s of a versioned update must be a Filter, got %T", operator, fields)
+		}
+		for field, value := range current {
+			// $rename changes the field named by its value too
+			target, _ := value.(string)
+			if isVersionField(field) || (operator == "$rename" && isVersionField(target)) {
+				return nil, errors.BadRequest.Newf("%s of a versioned update must not change %s", operator, VersionField)
+			}
+		}
+		versioned[operator] = fields
+	}
+
+	inc := Filter{}
+	if fields, ok := update["$inc"]; ok {
+		current, _ := filterOf(fields)
+		for field, value := range current {
+			inc[field] = value
+		}
+	}
+	inc[VersionField] = 1
+	versioned["$inc"] = inc
+
+	return versioned, nil
+}
+
+// isVersionField reports whether the field path is the version or a field in it
+func isVersionField(field string) bool {
+	return field == VersionField || strings.HasPrefix(field, VersionField+".")
+}
+
+func filterOf(v interface{}) (Filter, bool) {
+	switch v := v.(type) {
+	case Filter:
+		return v, true
+	case map[string]interface{}:
+		return v, true
+	default:
+		return nil, false
+	}
+}
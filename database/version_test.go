@@ -0,0 +1,70 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+func TestIncVersion(t *testing.T) {
+	update := Filter{
+		"$set": Filter{"name": "Dell"},
+		"$inc": map[string]interface{}{"stock": 2},
+	}
+
+	versioned, err := incVersion(update)
+	if err != nil {
+		t.Fatalf("incVersion: %v", err)
+	}
+
+	want := Filter{
+		"$set": Filter{"name": "Dell"},
+		"$inc": Filter{"stock": 2, VersionField: 1},
+	}
+	if !reflect.DeepEqual(versioned, want) {
+		t.Errorf("incVersion = %v, want %v", versioned, want)
+	}
+	if _, ok := update["$inc"].(map[string]interface{})[VersionField]; ok {
+		t.Error("incVersion changed the update")
+	}
+}
+
+func TestIncVersionInvalid(t *testing.T) {
+	tests := map[string]Filter{
+		"replacement":     {"name": "Dell"},
+		"set version":     {"$set": Filter{VersionField: 3}},
+		"set in version":  {"$set": Filter{VersionField + ".major": 3}},
+		"unset version":   {"$unset": Filter{VersionField: ""}},
+		"inc version":     {"$inc": Filter{VersionField: 2}},
+		"rename version":  {"$rename": Filter{VersionField: "old_version"}},
+		"rename to":       {"$rename": Filter{"revision": VersionField}},
+		"not a filter":    {"$set": struct{ Name string }{"Dell"}},
+		"inc not filter":  {"$inc": []int{1}},
+		"max version":     {"$max": map[string]interface{}{VersionField: 10}},
+		"current date":    {"$currentDate": Filter{VersionField: true}},
+		"set on insert":   {"$setOnInsert": Filter{VersionField: 0}},
+		"multiply":        {"$mul": Filter{VersionField: 2}},
+		"version in list": {"$set": Filter{"name": "Dell"}, "$min": Filter{VersionField: 0}},
+	}
+
+	for name, update := range tests {
+		if _, err := incVersion(update); errors.GetType(err) != errors.BadRequest {
+			t.Errorf("%s: incVersion = %v, want a BadRequest error", name, err)
+		}
+	}
+}
+
+func TestWithVersion(t *testing.T) {
+	selector := Filter{"code": "dell"}
+
+	want := Filter{"$and": []Filter{selector, {VersionField: int64(3)}}}
+	if got := withVersion(selector, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("withVersion = %v, want %v", got, want)
+	}
+
+	want = Filter{VersionField: Filter{"$in": []interface{}{0, nil}}}
+	if got := withVersion(nil, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("withVersion of version 0 = %v, want %v", got, want)
+	}
+}
@@ -23,6 +23,12 @@ func (err customError) Error() string {
 	return err.originalError.Error()
 }
 
+// Unwrap returns the original error, so errors.Is and errors.As of the standard
+// library reach the wrapped errors
+func (err customError) Unwrap() error {
+	return err.originalError
+}
+
 func (err customError) Stacktrace() string {
 	return fmt.Sprintf("%+v\n", err.originalError)
 }
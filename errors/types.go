@@ -22,6 +22,8 @@ const (
 	DeserializationError ErrorType = -11
 	CacheSetError        ErrorType = -12
 	CacheRemoveError     ErrorType = -13
+	Timeout              ErrorType = -14
+	Conflict             ErrorType = -15
 )
 
 type ErrorType int
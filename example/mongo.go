@@ -1,11 +1,9 @@
 package main
 
 import (
+	"context"
 	"log"
 
-	"gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
-
 	db "github.com/quangdangfit/gosdk/database"
 	"github.com/quangdangfit/gosdk/database/mongo"
 	"github.com/quangdangfit/gosdk/utils/logger"
@@ -19,7 +17,7 @@ type Brand struct {
 }
 
 func index() {
-	index := mgo.Index{
+	index := db.IndexConfig{
 		Key:        []string{"code"},
 		Unique:     true,
 		DropDups:   false,
@@ -54,19 +52,19 @@ func AddManyBrand() {
 }
 
 func UpdateBrand() {
-	filter := bson.M{"code": "code"}
-	update := bson.M{"$set": map[string]string{"code": "quang1"}}
+	filter := db.Filter{"code": "code"}
+	update := db.Filter{"$set": map[string]string{"code": "quang1"}}
 	Database.UpdateOne("brand", filter, update)
 }
 
 func UpdateManyBrand() {
-	filter := bson.M{"code": "code"}
-	update := bson.M{"$set": map[string]string{"code": "quang1"}}
+	filter := db.Filter{"code": "code"}
+	update := db.Filter{"$set": map[string]string{"code": "quang1"}}
 	Database.UpdateMany("brand", filter, update)
 }
 
 func DeleteBrand() {
-	filter := bson.M{"code": "quang"}
+	filter := db.Filter{"code": "quang"}
 	Database.DeleteOne("brand", filter)
 	Database.DeleteMany("brand", filter)
 }
@@ -92,7 +90,13 @@ func main() {
 		Database:     "test",
 	}
 
-	Database = mongo.NewMongo(dbConfig)
+	var err error
+	Database, err = mongo.DialMongo(dbConfig)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	defer Database.Close(context.Background())
 
 	//var result = Brand{
 	//	Code: "ASUS",
@@ -100,15 +104,21 @@ func main() {
 	//}
 	var results []Brand
 
-	//filter := bson.M{"code": "DELL"}
-	Database.FindMany("brands", nil, "-_id", &results)
+	//filter := db.Filter{"code": "DELL"}
+	Database.FindMany("brands", nil, &db.FindOptions{Sort: db.Sort{"-_id"}}, &results)
 
 	for _, e := range results {
 		log.Println(e.Name, e.Code)
 	}
 
-	database := mongo.NewWithConfig(dbConfig)
-	err := database.FindMany("brands", nil, nil, &results)
+	database, err := mongo.ConnectWithConfig(dbConfig)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	defer database.Close(context.Background())
+
+	err = database.FindMany("brands", nil, nil, &results)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -0,0 +1,208 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	"github.com/quangdangfit/gosdk/cache"
+	"github.com/quangdangfit/gosdk/utils/logger"
+)
+
+const (
+	recordPrefix = "idempotency:"
+	lockPrefix   = "idempotency:lock:"
+)
+
+var ctx = context.Background()
+
+var (
+	// ErrInProgress is returned when a request with the same key is being processed
+	ErrInProgress = errors.New("a request with the same idempotency key is in progress")
+	// ErrMismatch is returned when the key was used by a different request
+	ErrMismatch = errors.New("idempotency key was used with a different request")
+)
+
+// Response is the stored response of a completed request
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+type record struct {
+	Fingerprint string    `json:"fingerprint"`
+	Response    *Response `json:"response"`
+}
+
+// Store records in-flight and completed requests by idempotency key
+type Store interface {
+	// Begin marks the key as in-flight. It returns the stored response if the key
+	// has been completed, ErrInProgress if the key is in-flight and ErrMismatch if
+	// the fingerprint differs from the one of the completed request.
+	Begin(key string, fingerprint string) (*Response, error)
+	// Complete stores the response of the key and releases it.
+	Complete(key string, resp *Response) error
+	// Abort releases the key without storing any response, so the request can be
+	// retried.
+	Abort(key string) error
+}
+
+type store struct {
+	cmd         goredis.Cmdable
+	locker      cache.Locker
+	expiration  time.Duration
+	lockTimeout time.Duration
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a request holding the lock of its key
+type flight struct {
+	token       string
+	fingerprint string
+	stop        chan struct{}
+}
+
+// New creates an idempotency store, completed responses are saved in redis
+// through the raw client, so they never reach the cache logs, and in-flight
+// requests are guarded by the locker. The lock is refreshed while the request
+// is in-flight, the lock timeout only bounds how long the key stays locked
+// after the owner died.
+func New(rdb goredis.Cmdable, locker cache.Locker, opts ...Option) Store {
+	opt := getOption(opts...)
+
+	lockTimeout := opt.lockTimeout
+	if lockTimeout <= 0 {
+		lockTimeout = DefaultLockTimeout
+	}
+
+	return &store{
+		cmd:         rdb,
+		locker:      locker,
+		expiration:  opt.expiration,
+		lockTimeout: lockTimeout,
+		flights:     make(map[string]*flight),
+	}
+}
+
+func (s *store) Begin(key string, fingerprint string) (*Response, error) {
+	resp, err := s.lookup(key, fingerprint)
+	if err != nil || resp != nil {
+		return resp, err
+	}
+
+	token, err := s.locker.Lock(lockPrefix+key, s.lockTimeout)
+	if err == cache.ErrLockNotObtained {
+		return nil, ErrInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The request may have been completed between the lookup and the lock
+	resp, err = s.lookup(key, fingerprint)
+	if err != nil || resp != nil {
+		s.locker.Unlock(lockPrefix+key, token)
+		return resp, err
+	}
+
+	f := &flight{token: token, fingerprint: fingerprint, stop: make(chan struct{})}
+	s.mu.Lock()
+	s.flights[key] = f
+	s.mu.Unlock()
+
+	go s.keepAlive(key, f)
+
+	return nil, nil
+}
+
+func (s *store) Complete(key string, resp *Response) error {
+	token, fingerprint := s.release(key)
+
+	data, err := json.Marshal(record{Fingerprint: fingerprint, Response: resp})
+	if err != nil {
+		s.locker.Unlock(lockPrefix+key, token)
+		return err
+	}
+
+	err = s.cmd.Set(ctx, recordPrefix+key, data, s.expiration).Err()
+	if err != nil {
+		logger.Errorf("Failed to save idempotency record %s: %s", key, err)
+		s.locker.Unlock(lockPrefix+key, token)
+		return err
+	}
+
+	return s.locker.Unlock(lockPrefix+key, token)
+}
+
+func (s *store) Abort(key string) error {
+	token, _ := s.release(key)
+	return s.locker.Unlock(lockPrefix+key, token)
+}
+
+func (s *store) lookup(key string, fingerprint string) (*Response, error) {
+	data, err := s.cmd.Get(ctx, recordPrefix+key).Bytes()
+	if err == goredis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Errorf("Failed to get idempotency record %s: %s", key, err)
+		return nil, err
+	}
+
+	var rec record
+	err = json.Unmarshal(data, &rec)
+	if err != nil {
+		logger.Error("Failed to deserialize idempotency record: ", err)
+		return nil, err
+	}
+
+	if rec.Fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+
+	return rec.Response, nil
+}
+
+// keepAlive refreshes the lock of the flight until it is released, it gives up
+// when the lock has been lost.
+func (s *store) keepAlive(key string, f *flight) {
+	ticker := time.NewTicker(s.lockTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			err := s.locker.Refresh(lockPrefix+key, f.token, s.lockTimeout)
+			if err == cache.ErrLockNotObtained {
+				logger.Errorf("Lost idempotency lock of %s", key)
+				return
+			}
+		}
+	}
+}
+
+// release stops refreshing the lock of the key, it returns the token and the
+// fingerprint of the flight.
+func (s *store) release(key string) (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.flights[key]
+	if !ok {
+		return "", ""
+	}
+	delete(s.flights, key)
+	close(f.stop)
+
+	return f.token, f.fingerprint
+}
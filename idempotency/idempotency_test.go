@@ -0,0 +1,58 @@
+package idempotency
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quangdangfit/gosdk/cache"
+)
+
+type fakeLocker struct {
+	cache.Locker
+	mu        sync.Mutex
+	refreshes int
+}
+
+func (f *fakeLocker) Refresh(string, string, time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.refreshes++
+	return nil
+}
+
+func (f *fakeLocker) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.refreshes
+}
+
+func TestKeepAlive(t *testing.T) {
+	locker := &fakeLocker{}
+	s := &store{locker: locker, lockTimeout: 30 * time.Millisecond, flights: make(map[string]*flight)}
+
+	f := &flight{token: "token", stop: make(chan struct{})}
+	s.flights["k1"] = f
+	stopped := make(chan struct{})
+	go func() {
+		s.keepAlive("k1", f)
+		close(stopped)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if token, _ := s.release("k1"); token != "token" {
+		t.Errorf("release = %s, want token", token)
+	}
+	<-stopped
+
+	refreshes := locker.count()
+	if refreshes == 0 {
+		t.Fatal("lock was not refreshed while in-flight")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if locker.count() != refreshes {
+		t.Errorf("lock was refreshed after release")
+	}
+}
@@ -0,0 +1,135 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/quangdangfit/gosdk/utils/logger"
+)
+
+// ReplayedHeader is set on responses which are replayed from the store
+const ReplayedHeader = "Idempotent-Replayed"
+
+// Middleware returns a net/http middleware which replays the stored response for
+// requests carrying an already completed idempotency key, and rejects concurrent
+// requests with the same key with 409 Conflict. Requests without the key are
+// passed through. Responses with a 5xx status are not stored so the request can
+// be retried.
+func Middleware(s Store, opts ...Option) func(http.Handler) http.Handler {
+	opt := getOption(opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(opt.header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			fingerprint, err := fingerprintOf(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			resp, err := s.Begin(key, fingerprint)
+			switch err {
+			case nil:
+			case ErrInProgress:
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			case ErrMismatch:
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			default:
+				logger.Error("Failed to begin idempotent request: ", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if resp != nil {
+				replay(w, resp)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w, statusCode: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					s.Abort(key)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.statusCode >= http.StatusInternalServerError {
+				return
+			}
+
+			err = s.Complete(key, &Response{
+				StatusCode: rec.statusCode,
+				Header:     w.Header().Clone(),
+				Body:       rec.body.Bytes(),
+			})
+			if err != nil {
+				logger.Error("Failed to complete idempotent request: ", err)
+			}
+			completed = true
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, resp *Response) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(resp.StatusCode)
+	w.Write(resp.Body)
+}
+
+// fingerprintOf hashes the method, path and body of the request, the body is
+// restored so the next handler can read it again.
+func fingerprintOf(r *http.Request) (string, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			return "", err
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type recorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
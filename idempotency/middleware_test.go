@@ -0,0 +1,176 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memStore struct {
+	mu       sync.Mutex
+	records  map[string]record
+	inflight map[string]string
+	aborted  int
+}
+
+func newMemStore() *memStore {
+	return &memStore{records: make(map[string]record), inflight: make(map[string]string)}
+}
+
+func (m *memStore) Begin(key string, fingerprint string) (*Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rec, ok := m.records[key]; ok {
+		if rec.Fingerprint != fingerprint {
+			return nil, ErrMismatch
+		}
+		return rec.Response, nil
+	}
+	if _, ok := m.inflight[key]; ok {
+		return nil, ErrInProgress
+	}
+	m.inflight[key] = fingerprint
+
+	return nil, nil
+}
+
+func (m *memStore) Complete(key string, resp *Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records[key] = record{Fingerprint: m.inflight[key], Response: resp}
+	delete(m.inflight, key)
+
+	return nil
+}
+
+func (m *memStore) Abort(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.inflight, key)
+	m.aborted++
+
+	return nil
+}
+
+func serve(handler http.Handler, key string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/payments", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(DefaultHeader, key)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestMiddlewareReplay(t *testing.T) {
+	calls := 0
+	handler := Middleware(newMemStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", "/payments/1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	first := serve(handler, "k1", "amount=10")
+	second := serve(handler, "k1", "amount=10")
+
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != "created" {
+		t.Errorf("replay = %d %q, want %d %q", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+	if second.Header().Get("Location") != "/payments/1" || second.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("replay header = %v", second.Header())
+	}
+	if first.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("first response is marked as replayed")
+	}
+
+	serve(handler, "", "amount=10")
+	if calls != 2 {
+		t.Errorf("request without key was not passed through")
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	started, done := make(chan struct{}), make(chan struct{})
+	handler := Middleware(newMemStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-done
+	}))
+
+	finished := make(chan struct{})
+	go func() {
+		serve(handler, "k1", "amount=10")
+		close(finished)
+	}()
+	<-started
+
+	if w := serve(handler, "k1", "amount=10"); w.Code != http.StatusConflict {
+		t.Errorf("concurrent request = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	close(done)
+	<-finished
+}
+
+func TestMiddlewareMismatch(t *testing.T) {
+	handler := Middleware(newMemStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	serve(handler, "k1", "amount=10")
+	if w := serve(handler, "k1", "amount=20"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("request with another body = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestMiddlewareAbort(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		},
+		{
+			name: "panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			handler := Middleware(store)(tt.handler)
+
+			func() {
+				defer func() { recover() }()
+				serve(handler, "k1", "amount=10")
+			}()
+
+			if store.aborted != 1 || len(store.inflight) != 0 || len(store.records) != 0 {
+				t.Fatalf("store = %d aborted, %v in-flight, %v records, want the key released", store.aborted, store.inflight, store.records)
+			}
+
+			retried := Middleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			}))
+			if w := serve(retried, "k1", "amount=10"); w.Code != http.StatusOK || w.Header().Get(ReplayedHeader) != "" {
+				t.Errorf("retry = %d replayed %q, want a new response", w.Code, w.Header().Get(ReplayedHeader))
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package idempotency
+
+import (
+	"time"
+)
+
+const (
+	DefaultHeader      = "Idempotency-Key"
+	DefaultExpiration  = 24 * time.Hour
+	DefaultLockTimeout = time.Minute
+)
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	header      string
+	expiration  time.Duration
+	lockTimeout time.Duration
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithHeader sets the request header which carries the idempotency key, it is
+// used by Middleware, default is Idempotency-Key
+func WithHeader(header string) Option {
+	return optionFn(func(opt *option) {
+		opt.header = header
+	})
+}
+
+// WithExpiration sets how long a completed response is kept for replaying
+func WithExpiration(exp time.Duration) Option {
+	return optionFn(func(opt *option) {
+		opt.expiration = exp
+	})
+}
+
+// WithLockTimeout sets the expiration of the in-flight lock, the lock is
+// refreshed while the request runs, so it only bounds how long the key stays
+// locked after the owner died
+func WithLockTimeout(timeout time.Duration) Option {
+	return optionFn(func(opt *option) {
+		opt.lockTimeout = timeout
+	})
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{
+		header:      DefaultHeader,
+		expiration:  DefaultExpiration,
+		lockTimeout: DefaultLockTimeout,
+	}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+
+	return &opt
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/quangdangfit/gosdk/pubsub"
+)
+
+const bufferSize = 100
+
+type subscription struct {
+	messages chan *pubsub.Message
+	done     <-chan struct{}
+}
+
+type memoryPubSub struct {
+	mu            sync.RWMutex
+	subscriptions map[string]map[*subscription]struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates an in-process pub/sub, it is intended for tests and local
+// development. Messages are encoded like the other implementations, so handlers
+// never share memory with publishers.
+func New() pubsub.PubSub {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &memoryPubSub{
+		subscriptions: make(map[string]map[*subscription]struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+func (m *memoryPubSub) Publish(ctx context.Context, topic string, payload interface{}) error {
+	msg, err := pubsub.NewMessage(topic, payload)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for sub := range m.subscriptions[topic] {
+		var received pubsub.Message
+		if err := json.Unmarshal(data, &received); err != nil {
+			return err
+		}
+
+		select {
+		case sub.messages <- &received:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryPubSub) Subscribe(ctx context.Context, topic string, handler pubsub.Handler) error {
+	subCtx, cancel := context.WithCancel(ctx)
+	sub := &subscription{
+		messages: make(chan *pubsub.Message, bufferSize),
+		done:     subCtx.Done(),
+	}
+
+	m.mu.Lock()
+	if m.subscriptions[topic] == nil {
+		m.subscriptions[topic] = make(map[*subscription]struct{})
+	}
+	m.subscriptions[topic][sub] = struct{}{}
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			delete(m.subscriptions[topic], sub)
+			m.mu.Unlock()
+		}()
+		// Cancel first so publishers blocked on this subscription are released
+		defer cancel()
+
+		for {
+			select {
+			case msg := <-sub.messages:
+				handler(subCtx, msg)
+			case <-subCtx.Done():
+				return
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (m *memoryPubSub) Close() error {
+	m.cancel()
+	m.wg.Wait()
+	return nil
+}
@@ -0,0 +1,64 @@
+package pubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Message is the envelope of published payloads
+type Message struct {
+	ID        string          `json:"id"`
+	Topic     string          `json:"topic"`
+	Timestamp time.Time       `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// NewMessage wraps the payload of topic in an envelope with a new id and the
+// current timestamp
+func NewMessage(topic string, payload interface{}) (*Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		ID:        hex.EncodeToString(b),
+		Topic:     topic,
+		Timestamp: time.Now().UTC(),
+		Payload:   data,
+	}, nil
+}
+
+// Decode unmarshals the payload of message into value
+func (m *Message) Decode(value interface{}) error {
+	return json.Unmarshal(m.Payload, value)
+}
+
+// Handler is called for every message received on the subscribed topic
+type Handler func(ctx context.Context, msg *Message)
+
+type Publisher interface {
+	// Publish sends the payload to all subscribers of topic.
+	Publish(ctx context.Context, topic string, payload interface{}) error
+}
+
+type Subscriber interface {
+	// Subscribe calls the handler for messages of topic in background until
+	// the ctx is done or the subscriber is closed.
+	Subscribe(ctx context.Context, topic string, handler Handler) error
+	// Close stops all subscriptions.
+	Close() error
+}
+
+type PubSub interface {
+	Publisher
+	Subscriber
+}
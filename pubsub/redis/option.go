@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	prefix         string
+	healthInterval time.Duration
+	retryInterval  time.Duration
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithPrefix sets the prefix of redis channels, default is pubsub:
+func WithPrefix(prefix string) Option {
+	return optionFn(func(opt *option) {
+		opt.prefix = prefix
+	})
+}
+
+// WithHealthInterval sets how long a subscription waits for messages before it
+// pings the server to check the connection
+func WithHealthInterval(interval time.Duration) Option {
+	return optionFn(func(opt *option) {
+		opt.healthInterval = interval
+	})
+}
+
+// WithRetryInterval sets the delay before resubscribing after connection loss
+func WithRetryInterval(interval time.Duration) Option {
+	return optionFn(func(opt *option) {
+		opt.retryInterval = interval
+	})
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{
+		prefix:         "pubsub:",
+		healthInterval: 30 * time.Second,
+		retryInterval:  time.Second,
+	}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+
+	return &opt
+}
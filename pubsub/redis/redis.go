@@ -0,0 +1,187 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	cacheredis "github.com/quangdangfit/gosdk/cache/redis"
+	"github.com/quangdangfit/gosdk/pubsub"
+	"github.com/quangdangfit/gosdk/utils/logger"
+)
+
+type redisPubSub struct {
+	client *goredis.Client
+	opt    *option
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a pub/sub on redis channels, subscriptions are resubscribed
+// automatically when the connection is lost.
+func New(config cacheredis.Config, opts ...Option) (pubsub.PubSub, error) {
+	client, err := cacheredis.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &redisPubSub{
+		client: client,
+		opt:    getOption(opts...),
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+func (r *redisPubSub) Publish(ctx context.Context, topic string, payload interface{}) error {
+	msg, err := pubsub.NewMessage(topic, payload)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	err = r.client.Publish(ctx, r.opt.prefix+topic, data).Err()
+	if err != nil {
+		logger.Errorf("Failed to publish to %s: %s", topic, err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *redisPubSub) Subscribe(ctx context.Context, topic string, handler pubsub.Handler) error {
+	ps, err := r.subscribe(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-r.ctx.Done():
+		case <-subCtx.Done():
+		}
+		cancel()
+	}()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		r.receive(subCtx, topic, ps, handler)
+	}()
+
+	return nil
+}
+
+func (r *redisPubSub) Close() error {
+	r.cancel()
+	r.wg.Wait()
+	return r.client.Close()
+}
+
+func (r *redisPubSub) subscribe(ctx context.Context, topic string) (*goredis.PubSub, error) {
+	ps := r.client.Subscribe(ctx, r.opt.prefix+topic)
+
+	// Wait for the confirmation so the subscription is active when returning
+	_, err := ps.Receive(ctx)
+	if err != nil {
+		ps.Close()
+		logger.Errorf("Failed to subscribe %s: %s", topic, err)
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+// receive dispatches messages to the handler, the subscription is recreated
+// when the connection does not answer the health check ping or fails. A single
+// watcher closes the current subscription when the ctx is done, so a blocked
+// receive returns.
+func (r *redisPubSub) receive(ctx context.Context, topic string, ps *goredis.PubSub, handler pubsub.Handler) {
+	var mu sync.Mutex
+	current := ps
+	closeCurrent := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		current.Close()
+	}
+	defer closeCurrent()
+	go func() {
+		<-ctx.Done()
+		closeCurrent()
+	}()
+
+	pinged := false
+	for ctx.Err() == nil {
+		received, err := ps.ReceiveTimeout(ctx, r.opt.healthInterval)
+		if err == nil {
+			pinged = false
+			if msg, ok := received.(*goredis.Message); ok {
+				r.dispatch(ctx, topic, msg, handler)
+			}
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() && !pinged {
+			pinged = true
+			if err = ps.Ping(ctx); err == nil {
+				continue
+			}
+		}
+
+		logger.Warnf("Subscription of %s is lost, resubscribing: %s", topic, err)
+		ps.Close()
+		ps = r.resubscribe(ctx, topic)
+		if ps == nil {
+			return
+		}
+		pinged = false
+
+		mu.Lock()
+		current = ps
+		mu.Unlock()
+	}
+}
+
+func (r *redisPubSub) resubscribe(ctx context.Context, topic string) *goredis.PubSub {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.opt.retryInterval):
+		}
+
+		ps, err := r.subscribe(ctx, topic)
+		if err == nil {
+			logger.Info("Resubscribed to ", topic)
+			return ps
+		}
+	}
+}
+
+func (r *redisPubSub) dispatch(ctx context.Context, topic string, received *goredis.Message, handler pubsub.Handler) {
+	var msg pubsub.Message
+	err := json.Unmarshal([]byte(received.Payload), &msg)
+	if err != nil {
+		logger.Errorf("Failed to deserialize message of %s: %s", topic, err)
+		return
+	}
+
+	handler(ctx, &msg)
+}
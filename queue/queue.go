@@ -0,0 +1,40 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Job is a unit of work enqueued to a topic
+type Job struct {
+	ID         string          `json:"id"`
+	Topic      string          `json:"topic"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempt    int             `json:"attempt"`
+	EnqueuedAt time.Time       `json:"enqueued_at"`
+}
+
+// Decode unmarshals the payload of job into value
+func (j *Job) Decode(value interface{}) error {
+	return json.Unmarshal(j.Payload, value)
+}
+
+// Handler processes a job, the job will be retried with backoff if the handler
+// returns an error, and moved to the dead-letter stream after max retries.
+type Handler func(ctx context.Context, job *Job) error
+
+type Queue interface {
+	// Enqueue adds a job with the payload to the topic, it returns the job id.
+	Enqueue(ctx context.Context, topic string, payload interface{}) (string, error)
+	// EnqueueIn adds a job which will not be processed before the delay passed.
+	EnqueueIn(ctx context.Context, topic string, payload interface{}, delay time.Duration) (string, error)
+	// Handle registers the handler of topic, which is run by a pool of concurrency
+	// workers. Handlers must be registered before Start.
+	Handle(topic string, handler Handler, concurrency int)
+	// Start runs the workers of the registered handlers in background.
+	Start() error
+	// Stop stops fetching new jobs and waits for running jobs to finish, the
+	// running jobs are cancelled when the ctx is done.
+	Stop(ctx context.Context) error
+}
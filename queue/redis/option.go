@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	prefix       string
+	group        string
+	concurrency  int
+	maxRetries   int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	blockTimeout time.Duration
+	claimIdle    time.Duration
+	pollInterval time.Duration
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithPrefix sets the prefix of redis keys used by the queue, default is queue:
+func WithPrefix(prefix string) Option {
+	return optionFn(func(opt *option) {
+		opt.prefix = prefix
+	})
+}
+
+// WithGroup sets the consumer group name, workers in the same group share jobs
+func WithGroup(group string) Option {
+	return optionFn(func(opt *option) {
+		opt.group = group
+	})
+}
+
+// WithConcurrency sets the default number of workers of a topic
+func WithConcurrency(concurrency int) Option {
+	return optionFn(func(opt *option) {
+		opt.concurrency = concurrency
+	})
+}
+
+// WithMaxRetries sets the number of attempts before a job is dead-lettered, it
+// also limits the deliveries of a job which is never acknowledged
+func WithMaxRetries(retries int) Option {
+	return optionFn(func(opt *option) {
+		opt.maxRetries = retries
+	})
+}
+
+// WithBackoff sets the base and max delay of the exponential retry backoff
+func WithBackoff(base, max time.Duration) Option {
+	return optionFn(func(opt *option) {
+		opt.backoff = base
+		opt.maxBackoff = max
+	})
+}
+
+// WithClaimIdle sets how long a job can stay unacknowledged before it is claimed
+// by another worker, it should be longer than the longest running job
+func WithClaimIdle(idle time.Duration) Option {
+	return optionFn(func(opt *option) {
+		opt.claimIdle = idle
+	})
+}
+
+// WithPollInterval sets how often delayed jobs and stale jobs are checked
+func WithPollInterval(interval time.Duration) Option {
+	return optionFn(func(opt *option) {
+		opt.pollInterval = interval
+	})
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{
+		prefix:       "queue:",
+		group:        "workers",
+		concurrency:  1,
+		maxRetries:   5,
+		backoff:      time.Second,
+		maxBackoff:   time.Hour,
+		blockTimeout: 5 * time.Second,
+		claimIdle:    5 * time.Minute,
+		pollInterval: time.Second,
+	}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+
+	return &opt
+}
@@ -0,0 +1,556 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	cacheredis "github.com/quangdangfit/gosdk/cache/redis"
+	"github.com/quangdangfit/gosdk/queue"
+	"github.com/quangdangfit/gosdk/utils/logger"
+)
+
+const (
+	jobField = "job"
+	// claimPageSize is the number of pending jobs listed at once by claim
+	claimPageSize = 100
+)
+
+// moveDelayedScript moves the due jobs from the delayed set of a topic to its
+// stream, both keys share the hash tag of the topic so the script runs on a
+// single slot of redis cluster
+var moveDelayedScript = goredis.NewScript(`
+local jobs = redis.call("zrangebyscore", KEYS[1], "-inf", ARGV[1], "limit", 0, ARGV[2])
+for _, data in ipairs(jobs) do
+	redis.call("xadd", KEYS[2], "*", "job", data)
+	redis.call("zrem", KEYS[1], data)
+end
+return #jobs
+`)
+
+var errAlreadyStarted = errors.New("queue is already started")
+
+type topicHandler struct {
+	topic       string
+	handler     queue.Handler
+	concurrency int
+}
+
+type redisQueue struct {
+	client   *goredis.Client
+	opt      *option
+	consumer string
+	handlers map[string]*topicHandler
+
+	mu          sync.Mutex
+	started     bool
+	stopFetch   context.CancelFunc
+	cancelJobs  context.CancelFunc
+	fetchers    sync.WaitGroup
+	workers     sync.WaitGroup
+	workersDone chan struct{}
+}
+
+// New creates a job queue on redis streams, each topic is a stream consumed by
+// a consumer group, delayed and retried jobs wait in a sorted set until they
+// are due, and jobs failed after max retries are moved to a dead-letter stream.
+func New(config cacheredis.Config, opts ...Option) (queue.Queue, error) {
+	client, err := cacheredis.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisQueue{
+		client:   client,
+		opt:      getOption(opts...),
+		consumer: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), id[:8]),
+		handlers: make(map[string]*topicHandler),
+	}, nil
+}
+
+func (q *redisQueue) Enqueue(ctx context.Context, topic string, payload interface{}) (string, error) {
+	job, data, err := q.newJob(topic, payload)
+	if err != nil {
+		return "", err
+	}
+
+	err = q.client.XAdd(ctx, &goredis.XAddArgs{
+		Stream: q.streamKey(topic),
+		Values: map[string]interface{}{jobField: data},
+	}).Err()
+	if err != nil {
+		logger.Errorf("Failed to enqueue job to %s: %s", topic, err)
+		return "", err
+	}
+
+	return job.ID, nil
+}
+
+func (q *redisQueue) EnqueueIn(ctx context.Context, topic string, payload interface{}, delay time.Duration) (string, error) {
+	if delay <= 0 {
+		return q.Enqueue(ctx, topic, payload)
+	}
+
+	job, data, err := q.newJob(topic, payload)
+	if err != nil {
+		return "", err
+	}
+
+	err = q.client.ZAdd(ctx, q.delayedKey(topic), &goredis.Z{
+		Score:  float64(time.Now().Add(delay).UnixNano() / int64(time.Millisecond)),
+		Member: data,
+	}).Err()
+	if err != nil {
+		logger.Errorf("Failed to enqueue delayed job to %s: %s", topic, err)
+		return "", err
+	}
+
+	return job.ID, nil
+}
+
+func (q *redisQueue) Handle(topic string, handler queue.Handler, concurrency int) {
+	if concurrency <= 0 {
+		concurrency = q.opt.concurrency
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[topic] = &topicHandler{topic: topic, handler: handler, concurrency: concurrency}
+}
+
+func (q *redisQueue) Start() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started {
+		return errAlreadyStarted
+	}
+
+	fetchCtx, stopFetch := context.WithCancel(context.Background())
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+
+	for _, h := range q.handlers {
+		err := q.client.XGroupCreateMkStream(fetchCtx, q.streamKey(h.topic), q.opt.group, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			stopFetch()
+			cancelJobs()
+			logger.Errorf("Failed to create consumer group of %s: %s", h.topic, err)
+			return err
+		}
+	}
+
+	q.started = true
+	q.stopFetch = stopFetch
+	q.cancelJobs = cancelJobs
+	q.workersDone = make(chan struct{})
+
+	for _, h := range q.handlers {
+		messages := make(chan goredis.XMessage)
+
+		var producers sync.WaitGroup
+		producers.Add(2)
+		q.fetchers.Add(2)
+		go func(h *topicHandler) {
+			defer q.fetchers.Done()
+			defer producers.Done()
+			q.read(fetchCtx, h, messages)
+		}(h)
+		go func(h *topicHandler) {
+			defer q.fetchers.Done()
+			defer producers.Done()
+			q.claim(fetchCtx, h, messages)
+		}(h)
+		go func() {
+			producers.Wait()
+			close(messages)
+		}()
+
+		for i := 0; i < h.concurrency; i++ {
+			q.workers.Add(1)
+			go func(h *topicHandler) {
+				defer q.workers.Done()
+				for msg := range messages {
+					q.process(jobCtx, h, msg)
+				}
+			}(h)
+		}
+	}
+
+	topics := make([]string, 0, len(q.handlers))
+	for topic := range q.handlers {
+		topics = append(topics, topic)
+	}
+	q.fetchers.Add(1)
+	go func() {
+		defer q.fetchers.Done()
+		q.schedule(fetchCtx, topics)
+	}()
+
+	go func() {
+		q.workers.Wait()
+		close(q.workersDone)
+	}()
+
+	logger.Info("Queue started, consumer: ", q.consumer)
+	return nil
+}
+
+func (q *redisQueue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.started {
+		q.mu.Unlock()
+		return nil
+	}
+	q.started = false
+	q.mu.Unlock()
+
+	q.stopFetch()
+	stopped := make(chan struct{})
+	go func() {
+		q.fetchers.Wait()
+		<-q.workersDone
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		q.cancelJobs()
+		logger.Info("Queue stopped, consumer: ", q.consumer)
+		return nil
+	case <-ctx.Done():
+		q.cancelJobs()
+		logger.Warn("Queue stopped before running jobs finished, consumer: ", q.consumer)
+		return ctx.Err()
+	}
+}
+
+// read fetches new jobs of the topic for the workers
+func (q *redisQueue) read(ctx context.Context, h *topicHandler, messages chan<- goredis.XMessage) {
+	stream := q.streamKey(h.topic)
+	for ctx.Err() == nil {
+		streams, err := q.client.XReadGroup(ctx, &goredis.XReadGroupArgs{
+			Group:    q.opt.group,
+			Consumer: q.consumer,
+			Streams:  []string{stream, ">"},
+			Count:    int64(h.concurrency),
+			Block:    q.opt.blockTimeout,
+		}).Result()
+		if err != nil {
+			if err != goredis.Nil && ctx.Err() == nil {
+				logger.Errorf("Failed to read jobs of %s: %s", h.topic, err)
+				sleep(ctx, q.opt.pollInterval)
+			}
+			continue
+		}
+
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				if !send(ctx, messages, msg) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// claim takes over the jobs which were fetched by a worker but have not been
+// acknowledged for longer than claim idle, e.g. the worker crashed. The pending
+// list is paged so the stale jobs are found behind the running ones, the jobs
+// delivered more than max retries times are moved to the dead-letter stream as
+// they likely crash their workers.
+func (q *redisQueue) claim(ctx context.Context, h *topicHandler, messages chan<- goredis.XMessage) {
+	stream := q.streamKey(h.topic)
+	for sleep(ctx, q.opt.pollInterval) {
+		start := "-"
+		for ctx.Err() == nil {
+			pending, err := q.client.XPendingExt(ctx, &goredis.XPendingExtArgs{
+				Stream: stream,
+				Group:  q.opt.group,
+				Start:  start,
+				End:    "+",
+				Count:  claimPageSize,
+			}).Result()
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Errorf("Failed to get pending jobs of %s: %s", h.topic, err)
+				}
+				break
+			}
+
+			if !q.claimPage(ctx, h, pending, messages) {
+				break
+			}
+			next, ok := nextPage(pending)
+			if !ok {
+				break
+			}
+			start = next
+		}
+	}
+}
+
+// claimPage claims the stale jobs of a page of the pending list, it returns
+// false if the ctx is done
+func (q *redisQueue) claimPage(ctx context.Context, h *topicHandler, pending []goredis.XPendingExt, messages chan<- goredis.XMessage) bool {
+	stream := q.streamKey(h.topic)
+	ids, deliveries := q.stale(pending)
+	if len(ids) == 0 {
+		return true
+	}
+
+	claimed, err := q.client.XClaim(ctx, &goredis.XClaimArgs{
+		Stream:   stream,
+		Group:    q.opt.group,
+		Consumer: q.consumer,
+		MinIdle:  q.opt.claimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Errorf("Failed to claim pending jobs of %s: %s", h.topic, err)
+		}
+		return ctx.Err() == nil
+	}
+
+	for _, msg := range claimed {
+		if n := deliveries[msg.ID]; q.exhausted(int(n)) {
+			logger.Errorf("Job %s of %s was delivered %d times without being acknowledged", msg.ID, h.topic, n)
+			data, _ := msg.Values[jobField].(string)
+			q.deadLetter(ctx, stream, msg.ID, h.topic, data, fmt.Errorf("delivered %d times without being acknowledged", n))
+			continue
+		}
+		if !send(ctx, messages, msg) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// schedule moves delayed and retried jobs of the topics to their streams when
+// they are due
+func (q *redisQueue) schedule(ctx context.Context, topics []string) {
+	for sleep(ctx, q.opt.pollInterval) {
+		now := time.Now().UnixNano() / int64(time.Millisecond)
+		for _, topic := range topics {
+			err := moveDelayedScript.Run(ctx, q.client, []string{q.delayedKey(topic), q.streamKey(topic)},
+				strconv.FormatInt(now, 10), 100).Err()
+			if err != nil && ctx.Err() == nil {
+				logger.Errorf("Failed to move delayed jobs of %s: %s", topic, err)
+			}
+		}
+	}
+}
+
+func (q *redisQueue) process(ctx context.Context, h *topicHandler, msg goredis.XMessage) {
+	stream := q.streamKey(h.topic)
+	data, _ := msg.Values[jobField].(string)
+
+	var job queue.Job
+	err := json.Unmarshal([]byte(data), &job)
+	if err != nil {
+		logger.Errorf("Failed to deserialize job %s of %s: %s", msg.ID, h.topic, err)
+		q.deadLetter(ctx, stream, msg.ID, h.topic, data, err)
+		return
+	}
+
+	err = h.handler(ctx, &job)
+	if err == nil {
+		_, err = q.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			pipe.XAck(ctx, stream, q.opt.group, msg.ID)
+			pipe.XDel(ctx, stream, msg.ID)
+			return nil
+		})
+		if err != nil {
+			logger.Errorf("Failed to ack job %s of %s: %s", job.ID, h.topic, err)
+		}
+		return
+	}
+
+	job.Attempt++
+	if q.exhausted(job.Attempt) {
+		logger.Errorf("Job %s of %s failed after %d attempts: %s", job.ID, h.topic, job.Attempt, err)
+		retried, _ := json.Marshal(job)
+		q.deadLetter(ctx, stream, msg.ID, h.topic, string(retried), err)
+		return
+	}
+
+	delay := q.backoff(job.Attempt)
+	logger.Warnf("Job %s of %s failed, retry in %s: %s", job.ID, h.topic, delay, err)
+
+	retried, _ := json.Marshal(job)
+	_, err = q.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.ZAdd(ctx, q.delayedKey(h.topic), &goredis.Z{
+			Score:  float64(time.Now().Add(delay).UnixNano() / int64(time.Millisecond)),
+			Member: string(retried),
+		})
+		pipe.XAck(ctx, stream, q.opt.group, msg.ID)
+		pipe.XDel(ctx, stream, msg.ID)
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("Failed to retry job %s of %s: %s", job.ID, h.topic, err)
+	}
+}
+
+func (q *redisQueue) deadLetter(ctx context.Context, stream, id, topic, data string, cause error) {
+	_, err := q.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.XAdd(ctx, &goredis.XAddArgs{
+			Stream: q.deadKey(topic),
+			Values: map[string]interface{}{jobField: data, "error": cause.Error()},
+		})
+		pipe.XAck(ctx, stream, q.opt.group, id)
+		pipe.XDel(ctx, stream, id)
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("Failed to move job %s of %s to dead-letter: %s", id, topic, err)
+	}
+}
+
+// stale returns the ids of the pending jobs idle for longer than claim idle,
+// with the number of times each of them was delivered
+func (q *redisQueue) stale(pending []goredis.XPendingExt) ([]string, map[string]int64) {
+	deliveries := make(map[string]int64)
+	var ids []string
+	for _, p := range pending {
+		if p.Idle >= q.opt.claimIdle {
+			ids = append(ids, p.ID)
+			deliveries[p.ID] = p.RetryCount
+		}
+	}
+
+	return ids, deliveries
+}
+
+// exhausted reports whether a job which failed attempts times must be moved to
+// the dead-letter stream
+func (q *redisQueue) exhausted(attempts int) bool {
+	return attempts > q.opt.maxRetries
+}
+
+func (q *redisQueue) backoff(attempt int) time.Duration {
+	delay := q.opt.backoff
+	for i := 1; i < attempt && delay < q.opt.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.opt.maxBackoff {
+		delay = q.opt.maxBackoff
+	}
+
+	return delay
+}
+
+func (q *redisQueue) newJob(topic string, payload interface{}) (*queue.Job, string, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", err
+	}
+
+	job := &queue.Job{
+		ID:         id,
+		Topic:      topic,
+		Payload:    body,
+		EnqueuedAt: time.Now(),
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return job, string(data), nil
+}
+
+// streamKey returns the stream of the topic, the keys of a topic share the hash
+// tag {topic} so the scripts and transactions touching several of them stay on
+// a single slot of redis cluster
+func (q *redisQueue) streamKey(topic string) string {
+	return q.opt.prefix + "stream:{" + topic + "}"
+}
+
+func (q *redisQueue) deadKey(topic string) string {
+	return q.opt.prefix + "dead:{" + topic + "}"
+}
+
+func (q *redisQueue) delayedKey(topic string) string {
+	return q.opt.prefix + "delayed:{" + topic + "}"
+}
+
+// send passes the message to the workers, it returns false if the ctx is done
+// before a worker takes it
+func send(ctx context.Context, messages chan<- goredis.XMessage, msg goredis.XMessage) bool {
+	select {
+	case messages <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// nextPage returns the start of the page after pending, it returns false if
+// pending is the last page
+func nextPage(pending []goredis.XPendingExt) (string, bool) {
+	if len(pending) < claimPageSize {
+		return "", false
+	}
+
+	return nextID(pending[len(pending)-1].ID)
+}
+
+// nextID returns the smallest stream id after id
+func nextID(id string) (string, bool) {
+	i := strings.IndexByte(id, '-')
+	if i < 0 {
+		return "", false
+	}
+
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return "", false
+	}
+
+	return id[:i+1] + strconv.FormatUint(seq+1, 10), true
+}
+
+// sleep waits for the duration, it returns false if the ctx is done
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
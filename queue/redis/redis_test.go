@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+func TestBackoff(t *testing.T) {
+	q := &redisQueue{opt: getOption(WithBackoff(time.Second, 10*time.Second))}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := q.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestExhausted(t *testing.T) {
+	q := &redisQueue{opt: getOption(WithMaxRetries(3))}
+
+	for attempts := 1; attempts <= 3; attempts++ {
+		if q.exhausted(attempts) {
+			t.Errorf("exhausted(%d) = true, want a retry", attempts)
+		}
+	}
+	if !q.exhausted(4) {
+		t.Errorf("exhausted(4) = false, want dead-letter after 3 retries")
+	}
+}
+
+func TestStale(t *testing.T) {
+	q := &redisQueue{opt: getOption(WithClaimIdle(time.Minute))}
+	pending := []goredis.XPendingExt{
+		{ID: "1-0", Idle: 2 * time.Minute, RetryCount: 1},
+		{ID: "2-0", Idle: time.Second, RetryCount: 1},
+		{ID: "3-0", Idle: time.Minute, RetryCount: 7},
+	}
+
+	ids, deliveries := q.stale(pending)
+	if want := []string{"1-0", "3-0"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("stale = %q, want %q", ids, want)
+	}
+	if want := map[string]int64{"1-0": 1, "3-0": 7}; !reflect.DeepEqual(deliveries, want) {
+		t.Errorf("deliveries = %v, want %v", deliveries, want)
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	page := make([]goredis.XPendingExt, claimPageSize)
+	for i := range page {
+		page[i].ID = "1526985054069-" + strconv.Itoa(i)
+	}
+
+	next, ok := nextPage(page)
+	if want := "1526985054069-" + strconv.Itoa(claimPageSize); !ok || next != want {
+		t.Errorf("nextPage of a full page = %s %v, want %s", next, ok, want)
+	}
+	if _, ok := nextPage(page[:claimPageSize-1]); ok {
+		t.Errorf("nextPage of the last page = true, want false")
+	}
+	if _, ok := nextPage(nil); ok {
+		t.Errorf("nextPage of an empty page = true, want false")
+	}
+}
+
+func TestNextID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+		ok   bool
+	}{
+		{"1526985054069-0", "1526985054069-1", true},
+		{"0-9", "0-10", true},
+		{"invalid", "", false},
+		{"1-x", "", false},
+	}
+
+	for _, tt := range tests {
+		if got, ok := nextID(tt.id); got != tt.want || ok != tt.ok {
+			t.Errorf("nextID(%s) = %s %v, want %s %v", tt.id, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestKeysShareTopicSlot(t *testing.T) {
+	q := &redisQueue{opt: getOption()}
+
+	for _, key := range []string{q.streamKey("email"), q.deadKey("email"), q.delayedKey("email")} {
+		if got := hashTag(key); got != "email" {
+			t.Errorf("hash tag of %s = %q, want email", key, got)
+		}
+	}
+}
+
+// hashTag returns the part of key which redis cluster hashes
+func hashTag(key string) string {
+	start := -1
+	for i, c := range key {
+		switch {
+		case c == '{' && start < 0:
+			start = i
+		case c == '}' && start >= 0 && i > start+1:
+			return key[start+1 : i]
+		}
+	}
+
+	return key
+}
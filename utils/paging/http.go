@@ -0,0 +1,143 @@
+package paging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+const (
+	PageParam  = "page"
+	LimitParam = "limit"
+	SortParam  = "sort"
+)
+
+// Request is the page, limit and sort read from the query parameters
+type Request struct {
+	Page  int
+	Limit int
+	Sort  []string
+}
+
+// Envelope is the JSON body of a page, items is always an array
+type Envelope struct {
+	Items  interface{} `json:"items"`
+	Paging *Paging     `json:"paging"`
+}
+
+// Parse reads the page, limit and sort from the query parameters, e.g.
+// ?page=2&limit=20&sort=-created_at,name. The page starts at 1, the limit is
+// the default limit if missing and reduced to the max limit. A sort field may be
+// prefixed by - (minus) for reverse order, only the fields set by WithSortable
+// may be sorted by. Invalid parameters return a BadRequest error.
+func Parse(values url.Values, opts ...Option) (*Request, error) {
+	opt := getOption(opts...)
+	request := Request{Page: 1, Sort: opt.defaultSort}
+
+	if v := values.Get(PageParam); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return nil, errors.BadRequest.Newf("invalid %s %q, it must be a number from 1", PageParam, v)
+		}
+		request.Page = page
+	}
+
+	request.Limit = opt.limit(0)
+	if v := values.Get(LimitParam); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return nil, errors.BadRequest.Newf("invalid %s %q, it must be a number from 1", LimitParam, v)
+		}
+		request.Limit = opt.limit(limit)
+	}
+
+	var sort []string
+	for _, v := range values[SortParam] {
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if !opt.sortable[strings.TrimLeft(field, "+-")] {
+				return nil, errors.BadRequest.Newf("invalid %s, %q is not sortable", SortParam, field)
+			}
+			sort = append(sort, field)
+		}
+	}
+	if len(sort) > 0 {
+		request.Sort = sort
+	}
+
+	return &request, nil
+}
+
+// Link returns the RFC 5988 Link header of the page with the first, prev, next
+// and last pages, the links are u with the page and limit parameters replaced.
+// The last page is unknown for a page without total.
+func Link(u *url.URL, p *Paging) string {
+	var links []string
+	add := func(page int, rel string) {
+		link := *u
+		query := link.Query()
+		query.Set(PageParam, strconv.Itoa(page))
+		query.Set(LimitParam, strconv.Itoa(p.Limit))
+		link.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, link.String(), rel))
+	}
+
+	add(1, "first")
+	if p.HasPrev {
+		add(p.Current-1, "prev")
+	}
+	if p.HasNext {
+		add(p.Current+1, "next")
+	}
+	if p.TotalPage > 0 {
+		add(p.TotalPage, "last")
+	}
+
+	return strings.Join(links, ", ")
+}
+
+// NewEnvelope creates the JSON body of the page, nil items are an empty array
+func NewEnvelope(items interface{}, p *Paging) *Envelope {
+	if v := reflect.ValueOf(items); v.Kind() == reflect.Slice && v.IsNil() {
+		items = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	} else if items == nil {
+		items = []interface{}{}
+	}
+
+	return &Envelope{Items: items, Paging: p}
+}
+
+// Write writes the page as a JSON envelope with the Link header of the request,
+// the links are absolute URLs of the request host
+func Write(w http.ResponseWriter, r *http.Request, items interface{}, p *Paging) error {
+	w.Header().Set("Link", Link(RequestURL(r), p))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	return json.NewEncoder(w).Encode(NewEnvelope(items, p))
+}
+
+// RequestURL returns the absolute URL of the request, the scheme is https for a
+// TLS connection or the first X-Forwarded-Proto set by a proxy
+func RequestURL(r *http.Request) *url.URL {
+	u := *r.URL
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		u.Scheme = strings.ToLower(strings.TrimSpace(strings.Split(proto, ",")[0]))
+	}
+	u.Host = r.Host
+
+	return &u
+}
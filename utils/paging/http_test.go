@@ -0,0 +1,83 @@
+package paging
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/quangdangfit/gosdk/errors"
+)
+
+func TestParse(t *testing.T) {
+	values, _ := url.ParseQuery("page=2&limit=500&sort=-created_at,name")
+	request, err := Parse(values, WithSortable("created_at", "name"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := &Request{Page: 2, Limit: MaxLimit, Sort: []string{"-created_at", "name"}}
+	if !reflect.DeepEqual(request, want) {
+		t.Errorf("Parse = %+v, want %+v", request, want)
+	}
+
+	for _, query := range []string{"page=0", "page=a", "limit=-1", "sort=password"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := Parse(values, WithSortable("name")); errors.GetType(err) != errors.BadRequest {
+			t.Errorf("Parse(%s) = %v, want a BadRequest error", query, err)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := httptest.NewRequest("GET", "/brands?page=2&limit=10&q=dell", nil)
+	w := httptest.NewRecorder()
+
+	err := Write(w, r, []string(nil), New(2, 10, 35))
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	wantLink := `<http://example.com/brands?limit=10&page=1&q=dell>; rel="first", ` +
+		`<http://example.com/brands?limit=10&page=1&q=dell>; rel="prev", ` +
+		`<http://example.com/brands?limit=10&page=3&q=dell>; rel="next", ` +
+		`<http://example.com/brands?limit=10&page=4&q=dell>; rel="last"`
+	if link := w.Header().Get("Link"); link != wantLink {
+		t.Errorf("Link = %s, want %s", link, wantLink)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %s, want application/json", contentType)
+	}
+
+	var body struct {
+		Items  []string `json:"items"`
+		Paging Paging   `json:"paging"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if body.Items == nil || len(body.Items) != 0 || body.Paging.Current != 2 {
+		t.Errorf("body = %s, want empty items of page 2", w.Body.String())
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	r := httptest.NewRequest("GET", "/brands?page=2", nil)
+	r.Host = "api.example.com"
+	if got := RequestURL(r).String(); got != "http://api.example.com/brands?page=2" {
+		t.Errorf("RequestURL = %s", got)
+	}
+
+	r.TLS = &tls.ConnectionState{}
+	if got := RequestURL(r).String(); got != "https://api.example.com/brands?page=2" {
+		t.Errorf("RequestURL of TLS = %s", got)
+	}
+
+	r.TLS = nil
+	r.Header.Set("X-Forwarded-Proto", "https, http")
+	if got := RequestURL(r).String(); got != "https://api.example.com/brands?page=2" {
+		t.Errorf("RequestURL behind a proxy = %s", got)
+	}
+}
@@ -0,0 +1,100 @@
+package paging
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 50
+)
+
+type Option interface {
+	apply(*option)
+}
+
+type option struct {
+	defaultLimit int
+	maxLimit     int
+	skipTotal    bool
+	sortable     map[string]bool
+	defaultSort  []string
+}
+
+type optionFn func(*option)
+
+func (optFn optionFn) apply(opt *option) {
+	optFn(opt)
+}
+
+// WithDefaultLimit sets the limit of a page when no page size is given, it is
+// reduced to the max limit. Default is DefaultLimit.
+func WithDefaultLimit(limit int) Option {
+	return optionFn(func(opt *option) {
+		opt.defaultLimit = limit
+	})
+}
+
+// WithMaxLimit sets the maximum limit of a page, a bigger page size is reduced
+// to it. Default is MaxLimit.
+func WithMaxLimit(limit int) Option {
+	return optionFn(func(opt *option) {
+		opt.maxLimit = limit
+	})
+}
+
+// WithoutTotal skips counting the total of documents, which is expensive for
+// big collections. Total and TotalPage are UnknownTotal, HasNext is set by
+// reading one more document than the limit.
+func WithoutTotal() Option {
+	return optionFn(func(opt *option) {
+		opt.skipTotal = true
+	})
+}
+
+// WithSortable sets the fields which may be sorted by in the query parameters
+// read by Parse, a sort by another field is rejected.
+func WithSortable(fields ...string) Option {
+	return optionFn(func(opt *option) {
+		if opt.sortable == nil {
+			opt.sortable = make(map[string]bool)
+		}
+		for _, field := range fields {
+			opt.sortable[field] = true
+		}
+	})
+}
+
+// WithDefaultSort sets the sort returned by Parse when the query parameters
+// have no sort.
+func WithDefaultSort(fields ...string) Option {
+	return optionFn(func(opt *option) {
+		opt.defaultSort = fields
+	})
+}
+
+// limit returns the page size bounded by the options
+func (opt *option) limit(pageSize int) int {
+	switch {
+	case pageSize <= 0 && opt.defaultLimit > 0:
+		return opt.defaultLimit
+	case pageSize <= 0:
+		return DefaultLimit
+	case opt.maxLimit > 0 && pageSize > opt.maxLimit:
+		return opt.maxLimit
+	}
+
+	return pageSize
+}
+
+func getOption(opts ...Option) *option {
+	opt := option{
+		defaultLimit: DefaultLimit,
+		maxLimit:     MaxLimit,
+	}
+
+	for _, o := range opts {
+		o.apply(&opt)
+	}
+	if opt.maxLimit > 0 && opt.defaultLimit > opt.maxLimit {
+		opt.defaultLimit = opt.maxLimit
+	}
+
+	return &opt
+}
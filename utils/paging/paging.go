@@ -4,34 +4,54 @@ import (
 	"math"
 )
 
+// UnknownTotal is the total of a page made without counting the documents
+const UnknownTotal = -1
+
 type Paging struct {
-	Current   int `json:"current"`
-	Total     int `json:"total"`
-	TotalPage int `json:"total_page"`
-	Limit     int `json:"limit"`
-	Skip      int `json:"skip"`
+	Current   int  `json:"current"`
+	Total     int  `json:"total"`
+	TotalPage int  `json:"total_page"`
+	Limit     int  `json:"limit"`
+	Skip      int  `json:"skip"`
+	HasNext   bool `json:"has_next"`
+	HasPrev   bool `json:"has_prev"`
 }
 
-func New(page int, pageSize int, total int) *Paging {
+// New creates the paging of the page with the page size of total documents, the
+// page starts at 1. A page size out of 1 and the max limit is replaced by the
+// default limit or reduced to the max limit. A page after the last page is the
+// last page, and a page of no documents is the page 1 with skip 0.
+//
+// total may be UnknownTotal if the documents are not counted, then the page is
+// not bounded and HasNext must be set by the caller.
+func New(page int, pageSize int, total int, opts ...Option) *Paging {
+	opt := getOption(opts...)
+
 	var pageInfo Paging
-	limit := 50
+	pageInfo.Limit = opt.limit(pageSize)
 
-	if pageSize > 0 && pageSize <= limit {
-		pageInfo.Limit = pageSize
+	pageInfo.Total = total
+	if total == UnknownTotal {
+		pageInfo.TotalPage = UnknownTotal
 	} else {
-		pageInfo.Limit = limit
+		pageInfo.TotalPage = int(math.Ceil(float64(total) / float64(pageInfo.Limit)))
+		if page > pageInfo.TotalPage {
+			page = pageInfo.TotalPage
+		}
 	}
-
-	totalPage := int(math.Ceil(float64(total) / float64(pageInfo.Limit)))
-	pageInfo.Total = total
-	pageInfo.TotalPage = totalPage
 	if page < 1 {
 		page = 1
 	}
-	if page > totalPage {
-		page = totalPage
-	}
+
 	pageInfo.Current = page
 	pageInfo.Skip = (page - 1) * pageInfo.Limit
+	pageInfo.HasNext = total != UnknownTotal && page < pageInfo.TotalPage
+	pageInfo.HasPrev = page > 1
 	return &pageInfo
 }
+
+// CountTotal reports whether the total of documents should be counted with the
+// options, it is false with WithoutTotal.
+func CountTotal(opts ...Option) bool {
+	return !getOption(opts...).skipTotal
+}
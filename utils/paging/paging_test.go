@@ -0,0 +1,83 @@
+package paging
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     int
+		pageSize int
+		total    int
+		opts     []Option
+		want     Paging
+	}{
+		{
+			name: "first page", page: 1, pageSize: 10, total: 25,
+			want: Paging{Current: 1, Total: 25, TotalPage: 3, Limit: 10, Skip: 0, HasNext: true},
+		},
+		{
+			name: "middle page", page: 2, pageSize: 10, total: 25,
+			want: Paging{Current: 2, Total: 25, TotalPage: 3, Limit: 10, Skip: 10, HasNext: true, HasPrev: true},
+		},
+		{
+			name: "last page", page: 3, pageSize: 10, total: 25,
+			want: Paging{Current: 3, Total: 25, TotalPage: 3, Limit: 10, Skip: 20, HasPrev: true},
+		},
+		{
+			name: "after the last page", page: 9, pageSize: 10, total: 25,
+			want: Paging{Current: 3, Total: 25, TotalPage: 3, Limit: 10, Skip: 20, HasPrev: true},
+		},
+		{
+			name: "page before the first", page: -1, pageSize: 10, total: 25,
+			want: Paging{Current: 1, Total: 25, TotalPage: 3, Limit: 10, Skip: 0, HasNext: true},
+		},
+		{
+			name: "empty", page: 3, pageSize: 10, total: 0,
+			want: Paging{Current: 1, Total: 0, TotalPage: 0, Limit: 10, Skip: 0},
+		},
+		{
+			name: "exact pages", page: 2, pageSize: 10, total: 20,
+			want: Paging{Current: 2, Total: 20, TotalPage: 2, Limit: 10, Skip: 10, HasPrev: true},
+		},
+		{
+			name: "default limit", page: 1, pageSize: 0, total: 120,
+			want: Paging{Current: 1, Total: 120, TotalPage: 3, Limit: DefaultLimit, Skip: 0, HasNext: true},
+		},
+		{
+			name: "max limit", page: 2, pageSize: 1000, total: 120,
+			want: Paging{Current: 2, Total: 120, TotalPage: 3, Limit: MaxLimit, Skip: MaxLimit, HasNext: true, HasPrev: true},
+		},
+		{
+			name: "custom limits", page: 1, pageSize: 500, total: 500, opts: []Option{WithMaxLimit(200)},
+			want: Paging{Current: 1, Total: 500, TotalPage: 3, Limit: 200, Skip: 0, HasNext: true},
+		},
+		{
+			name: "default limit above max limit", page: 1, pageSize: 0, total: 100, opts: []Option{WithDefaultLimit(100), WithMaxLimit(20)},
+			want: Paging{Current: 1, Total: 100, TotalPage: 5, Limit: 20, Skip: 0, HasNext: true},
+		},
+		{
+			name: "unknown total", page: 4, pageSize: 10, total: UnknownTotal,
+			want: Paging{Current: 4, Total: UnknownTotal, TotalPage: UnknownTotal, Limit: 10, Skip: 30, HasPrev: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.page, tt.pageSize, tt.total, tt.opts...); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("New = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountTotal(t *testing.T) {
+	if !CountTotal() {
+		t.Error("CountTotal() = false, want true")
+	}
+	if CountTotal(WithoutTotal()) {
+		t.Error("CountTotal(WithoutTotal()) = true, want false")
+	}
+}